@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Run forward on every example of the batch in order
+func ForwardBatch(forward func([]*Value) []*Value, batch [][]*Value) [][]*Value {
+	outs := make([][]*Value, len(batch))
+	for i, x := range batch {
+		outs[i] = forward(x)
+	}
+	return outs
+}
+
+// Number of ForwardBatchParallel calls in progress
+var parallelForwards int32
+
+// Panic if a parallel forward is running, since the package state it reads
+// (the grad-enabled and debug flags, the package RNG) must not change under it
+func checkNotParallel(name string) {
+	if atomic.LoadInt32(&parallelForwards) > 0 {
+		panic(fmt.Sprintf("%s: not allowed during ForwardBatchParallel", name))
+	}
+}
+
+// Same as ForwardBatch but each example's subgraph is built on its own
+// goroutine. The subgraphs only read the shared parameters, and gradients are
+// accumulated into them by a single Backward on the caller's goroutine, so the
+// result is identical to the serial version. forward must not modify shared
+// state: calls that would, such as SetGradEnabled (used by Checkpoint) or
+// constructors drawing from the package RNG, panic, and the panic is re-raised
+// on the caller's goroutine
+func ForwardBatchParallel(forward func([]*Value) []*Value, batch [][]*Value) [][]*Value {
+	atomic.AddInt32(&parallelForwards, 1)
+	defer atomic.AddInt32(&parallelForwards, -1)
+
+	outs := make([][]*Value, len(batch))
+	panics := make([]interface{}, len(batch))
+	var wg sync.WaitGroup
+	for i, x := range batch {
+		wg.Add(1)
+		go func(i int, x []*Value) {
+			defer wg.Done()
+			defer func() {
+				panics[i] = recover()
+			}()
+			outs[i] = forward(x)
+		}(i, x)
+	}
+	wg.Wait()
+
+	for _, p := range panics {
+		if p != nil {
+			panic(p)
+		}
+	}
+	return outs
+}
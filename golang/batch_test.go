@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+// Batch of n examples with nin features each
+func batchOf(n, nin int) [][]*Value {
+	batch := make([][]*Value, n)
+	for i := range batch {
+		xs := make([]float64, nin)
+		for j := range xs {
+			xs[j] = float64((i*nin+j)%7)/3 - 1
+		}
+		batch[i] = values(xs...)
+	}
+	return batch
+}
+
+// Mean of the first output over the batch
+func meanOutput(outs [][]*Value) *Value {
+	firsts := make([]*Value, len(outs))
+	for i, out := range outs {
+		firsts[i] = out[0]
+	}
+	return Mul(AddN(firsts...), NewConst(1/float64(len(outs)), "1/n"))
+}
+
+// Run with go test -race to check the goroutines only read shared state
+func TestForwardBatchParallel(t *testing.T) {
+	model := NewMLPSeeded(3, 4, []int{8, 2})
+	batch := batchOf(32, 4)
+
+	serial := meanOutput(ForwardBatch(model.Forward, batch))
+	serial.Backward()
+	want := FlattenGrads(model.Parameters())
+
+	for run := 0; run < 5; run++ {
+		parallel := meanOutput(ForwardBatchParallel(model.Forward, batch))
+		if parallel.Data != serial.Data {
+			t.Fatalf("run %d: loss %v, want %v", run, parallel.Data, serial.Data)
+		}
+		parallel.Backward()
+		for i, g := range FlattenGrads(model.Parameters()) {
+			if g != want[i] {
+				t.Fatalf("run %d: grad %d is %v, want %v", run, i, g, want[i])
+			}
+		}
+	}
+}
+
+func TestForwardBatchParallelRejectsSharedWrites(t *testing.T) {
+	batch := batchOf(4, 2)
+	tests := []struct {
+		name    string
+		forward func([]*Value) []*Value
+	}{
+		{"checkpoint", func(x []*Value) []*Value {
+			return Checkpoint(func(vs []*Value) []*Value { return []*Value{Tanh(vs[0])} }, x)
+		}},
+		{"package rng", func(x []*Value) []*Value { return NewLinear(2, 1).Forward(x) }},
+		{"debug flag", func(x []*Value) []*Value {
+			defer SetDebugForward(true)()
+			return x
+		}},
+	}
+	for _, tt := range tests {
+		assertPanics(t, tt.name, func() { ForwardBatchParallel(tt.forward, batch) })
+	}
+
+	// the guard is lifted once the parallel forward has returned
+	SetGradEnabled(true)()
+	NewLinear(2, 1)
+}
+
+func benchmarkForward(b *testing.B, run func(func([]*Value) []*Value, [][]*Value) [][]*Value) {
+	model := NewMLPSeeded(1, 16, []int{16, 1})
+	batch := batchOf(64, 16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		run(model.Forward, batch)
+	}
+}
+
+func BenchmarkForwardBatch(b *testing.B) {
+	benchmarkForward(b, ForwardBatch)
+}
+
+// Compare with BenchmarkForwardBatch for the speedup
+func BenchmarkForwardBatchParallel(b *testing.B) {
+	benchmarkForward(b, ForwardBatchParallel)
+}
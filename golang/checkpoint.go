@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Fresh leaves holding the data of the given values
+func detachedCopies(vs []*Value) []*Value {
+	out := make([]*Value, len(vs))
+	for i, v := range vs {
+		out[i] = NewValue(v.Data, v.label)
+	}
+	return out
+}
+
+// Gradient checkpointing: run fn on the inputs without keeping its inner
+// activations, and re-run it once during the backward pass to propagate the
+// gradients of all outputs. Anything fn captures instead of taking as an input
+// is treated as a constant and gets no gradient, so pass trainable weights in
+// inputs
+func Checkpoint(fn func([]*Value) []*Value, inputs []*Value) []*Value {
+	restore := SetGradEnabled(false)
+	results := fn(detachedCopies(inputs))
+	restore()
+
+	// backward pass in which each output last received its gradient; outputs
+	// not reached by the current pass hold leftover gradients and are ignored
+	outs := make([]*Value, len(results))
+	reached := make([]uint64, len(results))
+
+	// every output hangs off one segment node, whose backward runs after the
+	// gradients of all outputs are final
+	segment := &Value{
+		Op:      OpCheckpoint,
+		parents: append([]*Value{}, inputs...),
+		label:   "checkpoint",
+	}
+
+	segment.backward = func() {
+		defer SetGradEnabled(true)()
+		leaves := detachedCopies(inputs)
+		roots := fn(leaves)
+
+		// only nodes that depend on the inputs belong to the segment
+		inner := map[*Value]bool{}
+		for _, leaf := range leaves {
+			inner[leaf] = true
+		}
+		order := []*Value{}
+		walkTopo(roots, func(n *Value) {
+			order = append(order, n)
+			for _, parent := range n.parents {
+				if inner[parent] {
+					inner[n] = true
+				}
+			}
+		})
+
+		saved := map[*Value]float64{}
+		for _, n := range order {
+			if !inner[n] {
+				saved[n] = n.Grad
+			}
+		}
+
+		pass := atomic.LoadUint64(&backwardPasses)
+		for j, root := range roots {
+			if inner[root] && reached[j] == pass {
+				root.Grad += outs[j].Grad
+			}
+		}
+		for i := len(order) - 1; i >= 0; i-- {
+			if inner[order[i]] {
+				order[i].backward()
+			}
+		}
+
+		// captured values are constants, undo what the segment pushed into them
+		for n, grad := range saved {
+			n.Grad = grad
+		}
+		for i, leaf := range leaves {
+			inputs[i].Grad += leaf.Grad
+		}
+	}
+	segment = track(segment)
+
+	for j, result := range results {
+		out := &Value{
+			Data:    result.Data,
+			Op:      OpCheckpoint,
+			parents: []*Value{segment},
+			label:   fmt.Sprintf("checkpoint[%d]", j),
+		}
+
+		// the segment reads out.Grad itself, adding it to segment.Grad only
+		// records the gradient flowing along the edge
+		out.backward = func() {
+			reached[j] = atomic.LoadUint64(&backwardPasses)
+			segment.Grad += out.Grad
+		}
+
+		outs[j] = track(out)
+	}
+
+	return outs
+}
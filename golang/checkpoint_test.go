@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Two tanh layers mixing x and y through the weights w
+func checkpointBlock(vs []*Value) []*Value {
+	x, y, w := vs[0], vs[1], vs[2]
+	h := Tanh(Add(Mul(w, x), y))
+	return []*Value{Tanh(Mul(h, x)), Mul(h, w)}
+}
+
+func TestCheckpoint(t *testing.T) {
+	loss := func(outs []*Value) *Value {
+		return Add(Mul(outs[0], outs[0]), Mul(NewValue(3, "k"), outs[1]))
+	}
+
+	plain := values(0.7, -0.4, 1.3)
+	want := loss(checkpointBlock(plain))
+	want.Backward()
+
+	inputs := values(0.7, -0.4, 1.3)
+	calls := 0
+	counted := func(vs []*Value) []*Value {
+		calls++
+		return checkpointBlock(vs)
+	}
+	got := loss(Checkpoint(counted, inputs))
+	assertClose(t, "forward", got.Data, want.Data, 1e-12)
+	if calls != 1 {
+		t.Fatalf("forward ran fn %d times, want 1", calls)
+	}
+
+	got.Backward()
+	if calls != 2 {
+		t.Errorf("backward ran fn %d times, want once for all outputs", calls-1)
+	}
+	for i := range inputs {
+		assertClose(t, "grad", inputs[i].Grad, plain[i].Grad, 1e-12)
+	}
+}
+
+func TestCheckpointCapturedValue(t *testing.T) {
+	w := NewParam(2, "w")
+	fn := func(vs []*Value) []*Value {
+		return []*Value{Mul(w, Mul(vs[0], vs[0]))}
+	}
+
+	x := NewValue(3, "x")
+	out := Checkpoint(fn, []*Value{x})[0]
+	for i := 0; i < 2; i++ {
+		out.Backward()
+		assertClose(t, "x grad", x.Grad, 2*2*3, 1e-12)
+		if w.Grad != 0 {
+			t.Errorf("pass %d: captured w got grad %g, want 0", i, w.Grad)
+		}
+	}
+}
+
+func TestCheckpointNoGrad(t *testing.T) {
+	inputs := values(0.7, -0.4, 1.3)
+	restore := SetGradEnabled(false)
+	outs := Checkpoint(checkpointBlock, inputs)
+	restore()
+
+	for _, out := range outs {
+		if len(out.Parents()) != 0 {
+			t.Errorf("%s built with grad disabled has parents", out.Label())
+		}
+	}
+}
+
+func TestCheckpointToDOTWithGrads(t *testing.T) {
+	inputs := values(0.7, -0.4, 1.3)
+	outs := Checkpoint(checkpointBlock, inputs)
+	loss := Add(Mul(outs[0], outs[0]), Mul(NewValue(3, "k"), outs[1]))
+	loss.Backward()
+	want := FlattenGrads(inputs)
+
+	// exporting re-runs every backward closure, including the checkpoint's
+	dot := loss.ToDOTWithGrads()
+	if strings.Contains(dot, "red") {
+		t.Errorf("checkpoint edges drawn as vanishing:\n%s", dot)
+	}
+	for _, g := range want {
+		if !strings.Contains(dot, fmt.Sprintf(`[label="%.4g"]`, g)) {
+			t.Errorf("no edge carries the input gradient %.4g:\n%s", g, dot)
+		}
+	}
+
+	loss.Backward()
+	for i, g := range FlattenGrads(inputs) {
+		assertClose(t, "grad after export", g, want[i], 1e-12)
+	}
+}
+
+func TestCheckpointSeparateLosses(t *testing.T) {
+	plain := values(0.7, -0.4, 1.3)
+	Tanh(checkpointBlock(plain)[0]).Backward()
+
+	inputs := values(0.7, -0.4, 1.3)
+	outs := Checkpoint(checkpointBlock, inputs)
+	first, second := Tanh(outs[0]), Exp(outs[1])
+	// the second output keeps its gradient from this pass, which must not
+	// leak into the next pass where it is unreachable
+	second.Backward()
+	first.Backward()
+	for i := range inputs {
+		assertClose(t, "grad", inputs[i].Grad, plain[i].Grad, 1e-12)
+	}
+}
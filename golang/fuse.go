@@ -0,0 +1,87 @@
+package main
+
+// Leaf created by NewConst; inputs from NewValue are not folded since their
+// data may change after fusing
+func isConst(v *Value) bool {
+	return v.Op == OpLeaf && v.constant
+}
+
+// Describe v as scale * input + shift when it is an affine op of a single
+// non-constant input
+func affineOf(v *Value) (scale, shift float64, input *Value, ok bool) {
+	switch v.Op {
+	case OpAffine:
+		return v.args[0], v.args[1], v.parents[0], true
+	case OpNeg:
+		return -1, 0, v.parents[0], true
+	case OpAdd, OpMul:
+		a, b := v.parents[0], v.parents[1]
+		if isConst(a) == isConst(b) {
+			return 0, 0, nil, false
+		}
+		if isConst(a) {
+			a, b = b, a
+		}
+		if v.Op == OpAdd {
+			return 1, b.Data, a, true
+		}
+		return b.Data, 0, a, true
+	}
+	return 0, 0, nil, false
+}
+
+// Rebuild the graph with every chain of constant-affine ops (adding or
+// multiplying by a NewConst leaf, Neg, Affine) collapsed into one Affine node.
+// Leaves are shared with the original graph so parameters still get their
+// gradients, but constant leaves absorbed into a fused node do not
+func Fuse(root *Value) *Value {
+	defer SetGradEnabled(true)()
+
+	// inner nodes of a chain can only be absorbed if nothing else uses them
+	uses := map[*Value]int{}
+	root.WalkTopo(func(n *Value) {
+		for _, parent := range n.parents {
+			uses[parent]++
+		}
+	})
+
+	fused := map[*Value]*Value{}
+	var build func(n *Value) *Value
+	build = func(n *Value) *Value {
+		if out, ok := fused[n]; ok {
+			return out
+		}
+
+		var out *Value
+		if scale, shift, input, ok := affineOf(n); ok {
+			for uses[input] == 1 {
+				s, c, next, ok := affineOf(input)
+				if !ok {
+					break
+				}
+				scale, shift, input = scale*s, scale*c+shift, next
+			}
+			out = Affine(build(input), scale, shift)
+		} else if n.Op == OpLeaf {
+			out = n
+		} else {
+			parents := make([]*Value, len(n.parents))
+			for i, parent := range n.parents {
+				parents[i] = build(parent)
+			}
+			rebuilt, err := rebuild(n.Op, parents, n.args, n.Data)
+			if err != nil {
+				// ops without a stored forward keep their original subgraph
+				rebuilt = n
+			} else {
+				rebuilt.label = n.label
+			}
+			out = rebuilt
+		}
+
+		fused[n] = out
+		return out
+	}
+
+	return build(root)
+}
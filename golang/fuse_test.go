@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestFuse(t *testing.T) {
+	build := func(x float64) (*Value, *Value, *Value) {
+		in, w := NewValue(x, "in"), NewParam(0.7, "w")
+		// 3 * (-(w*in + 2)) + 1 is one affine chain over w*in
+		h := Add(Mul(NewConst(3, "3"), Neg(Add(Mul(w, in), NewConst(2, "2")))), NewConst(1, "1"))
+		return Tanh(h), in, w
+	}
+
+	root, in, w := build(0.4)
+	fused := Fuse(root)
+	if got, want := len(TopoSort(fused)), len(TopoSort(root)); got >= want {
+		t.Errorf("fused graph has %d nodes, want fewer than %d", got, want)
+	}
+	assertClose(t, "data", fused.Data, root.Data, 1e-12)
+
+	fused.Backward()
+	want, wantIn, wantW := build(0.4)
+	want.Backward()
+	assertClose(t, "input grad", in.Grad, wantIn.Grad, 1e-12)
+	assertClose(t, "param grad", w.Grad, wantW.Grad, 1e-12)
+
+	// the input leaf stays in the fused graph, so a compiled copy follows it
+	compiled := Compile(fused, []*Value{in})
+	for _, x := range []float64{-1, 0, 2.5} {
+		want, _, _ := build(x)
+		assertClose(t, "compiled", compiled([]float64{x}), want.Data, 1e-12)
+	}
+}
+
+func TestFuseKeepsInputs(t *testing.T) {
+	// tanh(in * p): neither operand is a constant, so nothing is folded
+	in, p := NewValue(0.5, "in"), NewParam(-1.2, "p")
+	compiled := Compile(Fuse(Tanh(Mul(in, p))), []*Value{in})
+	for _, x := range []float64{-2, 0.3, 1.7} {
+		assertClose(t, "compiled", compiled([]float64{x}), Tanh(Mul(NewValue(x, "in"), p)).Data, 1e-12)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import "math"
+
+// Relative error |a - n| / (|a| + |n| + eps), comparable across ops of different scales
+func relError(analytic, numeric float64) float64 {
+	return math.Abs(analytic-numeric) / (math.Abs(analytic) + math.Abs(numeric) + 1e-12)
+}
+
+// Compare the backprop gradient of f at x with a central difference
+func RelGradError(f func(*Value) *Value, x float64) (analytic, numeric, relErr float64) {
+	in := NewValue(x, "x")
+	f(in).Backward()
+	analytic = in.Grad
+
+	numeric = NumGradDefault(func(xx float64) float64 {
+		return f(NewValue(xx, "x")).Data
+	}, x)
+
+	return analytic, numeric, relError(analytic, numeric)
+}
+
+// Relative error between the backprop and central-difference gradient of the
+// loss for each parameter; buildLoss must build a fresh graph on every call
+func CheckGradients(params []*Value, buildLoss func() *Value) []float64 {
+	buildLoss().Backward()
+	analytic := FlattenGrads(params)
+
+	errs := make([]float64, len(params))
+	for i, p := range params {
+		orig := p.Data
+		numeric := NumGradDefault(func(x float64) float64 {
+			p.Data = x
+			return buildLoss().Data
+		}, orig)
+		p.Data = orig
+		errs[i] = relError(analytic[i], numeric)
+	}
+	return errs
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+// Square whose backward pass forgets the factor 2
+func brokenSquare(a *Value) *Value {
+	return NewOp(a.Data*a.Data, []*Value{a}, func(out *Value) {
+		a.Grad += out.Grad * a.Data
+	}, "broken")
+}
+
+func TestCheckGradients(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     func(*Value) *Value
+		broken bool
+	}{
+		{"tanh", Tanh, false},
+		{"mish", Mish, false},
+		{"pow", func(a *Value) *Value { return Pow(a, 3) }, false},
+		{"broken square", brokenSquare, true},
+	}
+	for _, tt := range tests {
+		w, b := NewParam(0.7, "w"), NewParam(-0.3, "b")
+		x := NewValue(1.2, "x")
+		errs := CheckGradients([]*Value{w, b}, func() *Value {
+			return tt.op(Add(Mul(w, x), b))
+		})
+		for i, err := range errs {
+			if tt.broken && err < 0.1 {
+				t.Errorf("%s: param %d error %g, want a large error", tt.name, i, err)
+			}
+			if !tt.broken && err > 1e-6 {
+				t.Errorf("%s: param %d error %g, want near zero", tt.name, i, err)
+			}
+		}
+
+		_, _, relErr := RelGradError(tt.op, 0.8)
+		if tt.broken != (relErr > 0.1) {
+			t.Errorf("%s: RelGradError %g", tt.name, relErr)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unsafe"
+)
+
+// Rough size of a backward closure and the variables it captures
+const closureBytes = 64
+
+// Number of nodes reachable from v, each counted once, and an estimate of the
+// memory they retain: the struct, its parent and args slices and its closure
+func (v *Value) MemStats() (nodes int, bytesEstimate int64) {
+	for _, n := range TopoSort(v) {
+		nodes++
+		bytesEstimate += int64(unsafe.Sizeof(*n))
+		bytesEstimate += int64(cap(n.parents)) * int64(unsafe.Sizeof(n))
+		bytesEstimate += int64(cap(n.args)) * int64(unsafe.Sizeof(n.Data))
+		if n.backward != nil {
+			bytesEstimate += closureBytes
+		}
+	}
+	return nodes, bytesEstimate
+}
+
+// Stable 64-bit FNV-1a hash of the graph structure: the op kind, label and args
+// of every node and which nodes are its parents. Structurally identical graphs
+// hash equal regardless of their data
+func (v *Value) Hash() uint64 {
+	return v.hash(false)
+}
+
+// Like Hash but also covers the data of every node
+func (v *Value) HashWithData() uint64 {
+	return v.hash(true)
+}
+
+func (v *Value) hash(withData bool) uint64 {
+	h := fnv.New64a()
+	index := map[*Value]int{}
+	write := func(x uint64) {
+		binary.Write(h, binary.LittleEndian, x)
+	}
+
+	v.WalkTopo(func(n *Value) {
+		index[n] = len(index)
+		write(uint64(n.Op))
+		write(uint64(len(n.label)))
+		h.Write([]byte(n.label))
+		write(uint64(len(n.args)))
+		for _, a := range n.args {
+			write(math.Float64bits(a))
+		}
+		write(uint64(len(n.parents)))
+		for _, parent := range n.parents {
+			write(uint64(index[parent]))
+		}
+		if withData {
+			write(math.Float64bits(n.Data))
+		}
+	})
+	return h.Sum64()
+}
+
+// Length of the longest path from v down to a leaf. Each node's depth is
+// computed once from its parents', so shared subgraphs are not re-walked
+func (v *Value) Depth() int {
+	depth := map[*Value]int{}
+	v.WalkTopo(func(n *Value) {
+		d := 0
+		for _, parent := range n.parents {
+			d = max(d, depth[parent]+1)
+		}
+		depth[n] = d
+	})
+	return depth[v]
+}
+
+// Gradients below this magnitude are highlighted as vanishing in DOT output
+const vanishingGrad = 1e-6
+
+var dotEscaper = strings.NewReplacer(`"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`)
+
+// Export the graph in Graphviz DOT format, edges point from parents to children
+func (v *Value) ToDOT() string {
+	return v.toDOT(false)
+}
+
+// Export the graph in DOT format with every edge labelled by the gradient that
+// flowed along it in the last backward pass. Nodes and edges with a near-zero
+// gradient are drawn in red
+func (v *Value) ToDOTWithGrads() string {
+	return v.toDOT(true)
+}
+
+func (v *Value) toDOT(withGrads bool) string {
+	order := TopoSort(v)
+	ids := map[*Value]string{}
+	for i, n := range order {
+		ids[n] = fmt.Sprintf("n%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph G {\n\trankdir=LR;\n")
+	for _, n := range order {
+		attrs := ""
+		if withGrads && math.Abs(n.Grad) < vanishingGrad {
+			attrs = ", color=red, fontcolor=red"
+		}
+		fmt.Fprintf(&b, "\t%s [shape=record, label=\"{ %s | data %.4f | grad %.4g }\"%s];\n",
+			ids[n], dotEscaper.Replace(n.label), n.Data, n.Grad, attrs)
+	}
+	for _, n := range order {
+		var grads []float64
+		if withGrads {
+			grads = edgeGrads(n)
+		}
+		seen := map[*Value]bool{}
+		for i, parent := range n.parents {
+			// a parent used several times is drawn as one edge carrying the total
+			if seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			if !withGrads {
+				fmt.Fprintf(&b, "\t%s -> %s;\n", ids[parent], ids[n])
+				continue
+			}
+			attrs := ""
+			if math.Abs(grads[i]) < vanishingGrad {
+				attrs = ", color=red, fontcolor=red, style=dashed"
+			}
+			fmt.Fprintf(&b, "\t%s -> %s [label=\"%.4g\"%s];\n", ids[parent], ids[n], grads[i], attrs)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Gradient contributed by a node to each of its parents, measured by running its
+// backward closure in isolation and restoring the parents' gradients afterwards
+func edgeGrads(n *Value) []float64 {
+	saved := make([]float64, len(n.parents))
+	for i, parent := range n.parents {
+		saved[i] = parent.Grad
+	}
+	for _, parent := range n.parents {
+		parent.Grad = 0
+	}
+
+	n.backward()
+
+	grads := make([]float64, len(n.parents))
+	for i, parent := range n.parents {
+		grads[i] = parent.Grad
+	}
+	for i, parent := range n.parents {
+		parent.Grad = saved[i]
+	}
+	return grads
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The tanh(2x+3) graph from main
+func tanhSample(x float64) (f, xv *Value) {
+	xv = NewValue(x, "x")
+	f = Tanh(Add(Mul(NewValue(2, "2"), xv), NewValue(3, "3")))
+	return f, xv
+}
+
+func TestToDOTWithGrads(t *testing.T) {
+	f, _ := tanhSample(1)
+	f.Backward()
+	dot := f.ToDOTWithGrads()
+
+	// local gradient of tanh at z = 5, then scaled by 2 for x through the multiply
+	dz := 1 - math.Pow(math.Tanh(5), 2)
+	for _, edge := range []string{
+		fmt.Sprintf("n4 -> n5 [label=\"%.4g\"]", dz),
+		fmt.Sprintf("n1 -> n2 [label=\"%.4g\"]", 2*dz),
+		fmt.Sprintf("n0 -> n2 [label=\"%.4g\"]", dz),
+	} {
+		if !strings.Contains(dot, edge) {
+			t.Errorf("missing edge %q in\n%s", edge, dot)
+		}
+	}
+	if strings.Contains(dot, "color=red") {
+		t.Errorf("no gradient here is near zero, got\n%s", dot)
+	}
+
+	// exporting must leave the gradients untouched
+	assertClose(t, "x grad", TopoSort(f)[1].Grad, 2*dz, 1e-12)
+
+	// a saturated unit shows up as a vanishing gradient
+	g, _ := tanhSample(20)
+	g.Backward()
+	if !strings.Contains(g.ToDOTWithGrads(), "style=dashed") {
+		t.Error("expected a vanishing edge for a saturated tanh")
+	}
+}
+
+// n tanh nodes stacked on one leaf
+func tanhChain(n int) *Value {
+	v := NewValue(0.1, "x")
+	for i := 0; i < n; i++ {
+		v = Tanh(v)
+	}
+	return v
+}
+
+func TestMemStats(t *testing.T) {
+	out, _ := sampleGraph(0.5, 2, 3)
+	nodes, bytes := out.MemStats()
+	if nodes != len(TopoSort(out)) {
+		t.Errorf("nodes %d, want len(TopoSort) %d", nodes, len(TopoSort(out)))
+	}
+	if bytes <= 0 {
+		t.Errorf("bytes estimate %d, want positive", bytes)
+	}
+
+	// every extra tanh costs the same, so the estimate grows linearly
+	var estimates []int64
+	for _, n := range []int{10, 20, 30} {
+		nodes, bytes := tanhChain(n).MemStats()
+		if nodes != n+1 {
+			t.Errorf("chain of %d: nodes %d, want %d", n, nodes, n+1)
+		}
+		estimates = append(estimates, bytes)
+	}
+	if step := estimates[1] - estimates[0]; step <= 0 || estimates[2]-estimates[1] != step {
+		t.Errorf("estimates %v do not grow linearly", estimates)
+	}
+}
+
+func TestHash(t *testing.T) {
+	a, _ := sampleGraph(0.5, 2, 3)
+	b, _ := sampleGraph(-1.2, 2, 3)
+	if a.Hash() != b.Hash() {
+		t.Error("identical structure with different data: hashes differ")
+	}
+	if a.HashWithData() == b.HashWithData() {
+		t.Error("different data: HashWithData is equal")
+	}
+	if c, _ := sampleGraph(0.5, 2, 3); a.HashWithData() != c.HashWithData() {
+		t.Error("identical graphs: HashWithData differs")
+	}
+
+	// same labels, but the outer product uses a second leaf instead of sharing x
+	x, x2 := NewValue(0.5, "x"), NewValue(0.5, "x")
+	unshared := Mul(Tanh(Add(Mul(NewValue(2, "w"), x), NewValue(3, "b"))), x2)
+	if unshared.Hash() == a.Hash() {
+		t.Error("different topology: hashes are equal")
+	}
+
+	// same labels and shape, only the op kind differs
+	tanh, swish := Tanh(NewValue(1, "x")), Swish(NewValue(1, "x"))
+	swish.label = tanh.label
+	if tanh.Hash() == swish.Hash() {
+		t.Error("different op kind: hashes are equal")
+	}
+}
+
+func TestDepth(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 50} {
+		// n tanh nodes on a leaf make a chain of n+1 nodes
+		if d := tanhChain(n).Depth(); d != n {
+			t.Errorf("chain of %d nodes: depth %d, want %d", n+1, d, n)
+		}
+	}
+
+	// the tanh branch is 4 deep and the exp branch 1, plus the join
+	x := NewValue(0.3, "x")
+	long := x
+	for i := 0; i < 4; i++ {
+		long = Tanh(long)
+	}
+	if d := Add(long, Exp(x)).Depth(); d != 5 {
+		t.Errorf("diamond: depth %d, want the longer branch 5", d)
+	}
+
+	// 2^60 paths to the leaf: only finishes if shared nodes are visited once
+	v := NewValue(0.1, "x")
+	for i := 0; i < 60; i++ {
+		v = NewOp(0, []*Value{v, v}, func(*Value) {}, "d")
+	}
+	start := time.Now()
+	if d := v.Depth(); d != 60 {
+		t.Errorf("wide diamond: depth %d, want 60", d)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("wide diamond took %v", elapsed)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Huber value of a residual: quadratic within delta, linear beyond
+func huber(r, delta float64) float64 {
+	if math.Abs(r) <= delta {
+		return 0.5 * r * r
+	}
+	return delta * (math.Abs(r) - 0.5*delta)
+}
+
+// Huber loss between a prediction and a target, robust to outliers
+func HuberLoss(pred, target *Value, delta float64) *Value {
+	out := &Value{
+		Data:    huber(pred.Data-target.Data, delta),
+		Op:      OpHuber,
+		args:    []float64{delta},
+		parents: []*Value{pred, target},
+		label:   fmt.Sprintf("huber(%s, %s)", pred.label, target.label),
+	}
+
+	out.backward = func() {
+		r := pred.Data - target.Data
+		// residual inside delta, delta*sign(residual) outside
+		grad := r
+		if math.Abs(r) > delta {
+			grad = delta * math.Copysign(1, r)
+		}
+		pred.Grad += out.Grad * grad
+		target.Grad -= out.Grad * grad
+	}
+
+	return track(out)
+}
+
+// Smooth-L1 value of a residual: 0.5*r^2/beta within beta, |r| - 0.5*beta beyond
+func smoothL1(r, beta float64) float64 {
+	if math.Abs(r) < beta {
+		return 0.5 * r * r / beta
+	}
+	return math.Abs(r) - 0.5*beta
+}
+
+// Smooth-L1 loss as used in object detection. Unlike Huber, the quadratic region
+// is divided by beta so the linear region always has slope 1; with delta == beta
+// it equals HuberLoss / beta
+func SmoothL1Loss(pred, target *Value, beta float64) *Value {
+	out := &Value{
+		Data:    smoothL1(pred.Data-target.Data, beta),
+		Op:      OpSmoothL1,
+		args:    []float64{beta},
+		parents: []*Value{pred, target},
+		label:   fmt.Sprintf("smoothl1(%s, %s)", pred.label, target.label),
+	}
+
+	out.backward = func() {
+		r := pred.Data - target.Data
+		grad := r / beta
+		if math.Abs(r) >= beta {
+			grad = math.Copysign(1, r)
+		}
+		pred.Grad += out.Grad * grad
+		target.Grad -= out.Grad * grad
+	}
+
+	return track(out)
+}
+
+// Probabilities are clamped to at least klEps before taking logs, so a zero
+// entry gives a finite loss; clamped entries receive no gradient through the log
+const klEps = 1e-12
+
+// KL divergence sum(p_i * log(p_i/q_i)) of the clamped distributions
+func klDiv(p, q []*Value) float64 {
+	sum := 0.0
+	for i := range p {
+		sum += p[i].Data * (math.Log(math.Max(p[i].Data, klEps)) - math.Log(math.Max(q[i].Data, klEps)))
+	}
+	return sum
+}
+
+func klDivLoss(p, q []*Value, detachP bool) *Value {
+	if len(p) != len(q) {
+		panic(fmt.Sprintf("KLDivLoss: length mismatch %d != %d", len(p), len(q)))
+	}
+
+	parents := append(append([]*Value{}, p...), q...)
+	out := &Value{
+		Data:    klDiv(p, q),
+		Op:      OpKLDiv,
+		args:    []float64{mask(detachP)},
+		parents: parents,
+		label:   "kl(p || q)",
+	}
+
+	out.backward = func() {
+		for i := range p {
+			pc := math.Max(p[i].Data, klEps)
+			qc := math.Max(q[i].Data, klEps)
+			if !detachP {
+				grad := math.Log(pc) - math.Log(qc)
+				if p[i].Data > klEps {
+					grad += 1
+				}
+				p[i].Grad += out.Grad * grad
+			}
+			if q[i].Data > klEps {
+				q[i].Grad -= out.Grad * p[i].Data / qc
+			}
+		}
+	}
+
+	return track(out)
+}
+
+// KL divergence between two distributions, gradients flow into both p and q
+func KLDivLoss(p, q []*Value) *Value {
+	return klDivLoss(p, q, false)
+}
+
+// KL divergence against a constant target distribution p, only q receives gradient
+func KLDivLossTarget(p, q []*Value) *Value {
+	return klDivLoss(p, q, true)
+}
+
+// Cross-entropy of softmax(logits) against the target class, using the
+// log-sum-exp trick: the max logit is subtracted as a constant before exp
+func CrossEntropy(logits []*Value, target int) *Value {
+	if target < 0 || target >= len(logits) {
+		panic(fmt.Sprintf("CrossEntropy: target %d out of range for %d logits", target, len(logits)))
+	}
+
+	m := logits[0].Data
+	for _, l := range logits {
+		m = math.Max(m, l.Data)
+	}
+
+	shift := NewConst(-m, "-max")
+	exps := make([]*Value, len(logits))
+	for i, l := range logits {
+		exps[i] = Exp(Add(l, shift))
+	}
+	lse := Add(Log(AddN(exps...)), NewConst(m, "max"))
+
+	return Add(lse, Mul(NewConst(-1, "-1"), logits[target]))
+}
+
+// Mean cross-entropy over a batch of logit vectors
+func BatchCrossEntropy(logits [][]*Value, targets []int) *Value {
+	if len(logits) != len(targets) {
+		panic(fmt.Sprintf("BatchCrossEntropy: %d logit vectors but %d targets", len(logits), len(targets)))
+	}
+	if len(logits) == 0 {
+		panic("BatchCrossEntropy: empty batch")
+	}
+
+	losses := make([]*Value, len(logits))
+	for i := range logits {
+		losses[i] = CrossEntropy(logits[i], targets[i])
+	}
+
+	return Mul(AddN(losses...), NewConst(1/float64(len(logits)), "1/n"))
+}
+
+// Triplet margin loss max(0, d(anchor, positive) - d(anchor, negative) + margin)
+// with squared distances; a satisfied triplet gets no gradient
+func TripletLoss(anchor, positive, negative []*Value, margin float64) *Value {
+	dp := SquaredDistance(anchor, positive)
+	dn := SquaredDistance(anchor, negative)
+	return ReLU(Add(Sub(dp, dn), NewConst(margin, "margin")))
+}
+
+// Binary cross-entropy of sigmoid(z) against t in the stable form
+// max(z, 0) - z*t + log(1 + exp(-|z|))
+func bceWithLogits(z, t float64) float64 {
+	return math.Max(z, 0) - z*t + math.Log1p(math.Exp(-math.Abs(z)))
+}
+
+// Binary cross-entropy computed directly from the logit, so large logits don't
+// saturate a separate sigmoid; the logit gradient is sigmoid(z) - t
+func BCEWithLogitsLoss(logit, target *Value) *Value {
+	out := &Value{
+		Data:    bceWithLogits(logit.Data, target.Data),
+		Op:      OpBCEWithLogits,
+		parents: []*Value{logit, target},
+		label:   fmt.Sprintf("bce(%s, %s)", logit.label, target.label),
+	}
+
+	out.backward = func() {
+		logit.Grad += out.Grad * (sigmoid(logit.Data) - target.Data)
+		target.Grad -= out.Grad * logit.Data
+	}
+
+	return track(out)
+}
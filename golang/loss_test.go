@@ -0,0 +1,194 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHuberLoss(t *testing.T) {
+	const delta = 1.0
+	tests := []struct {
+		name     string
+		residual float64
+	}{
+		{"quadratic", 0.3},
+		{"quadratic negative", -0.7},
+		{"linear", 2.5},
+		{"linear negative", -4},
+	}
+	for _, tt := range tests {
+		pred, target := NewValue(tt.residual, "p"), NewValue(0, "t")
+		HuberLoss(pred, target, delta).Backward()
+
+		want := NumGradDefault(func(x float64) float64 { return huber(x, delta) }, tt.residual)
+		assertClose(t, tt.name+" pred grad", pred.Grad, want, 1e-6)
+		assertClose(t, tt.name+" target grad", target.Grad, -want, 1e-6)
+	}
+
+	// value and gradient are continuous at |r| == delta
+	const h = 1e-9
+	assertClose(t, "value continuity", huber(delta-h, delta), huber(delta+h, delta), 1e-8)
+	in, out := NewValue(delta-h, "p"), NewValue(delta+h, "p")
+	HuberLoss(in, NewValue(0, "t"), delta).Backward()
+	HuberLoss(out, NewValue(0, "t"), delta).Backward()
+	assertClose(t, "gradient continuity", in.Grad, out.Grad, 1e-8)
+}
+
+func TestKLDivLoss(t *testing.T) {
+	pd := []float64{0.2, 0.5, 0.3}
+	qd := []float64{0.1, 0.6, 0.3}
+	kl := func(p, q []float64) float64 {
+		sum := 0.0
+		for i := range p {
+			sum += p[i] * math.Log(p[i]/q[i])
+		}
+		return sum
+	}
+
+	p, q := values(pd...), values(qd...)
+	loss := KLDivLoss(p, q)
+	loss.Backward()
+	assertClose(t, "loss", loss.Data, kl(pd, qd), 1e-12)
+	for i := range pd {
+		wantP := partial(func(xs []float64) float64 { return kl(xs, qd) }, pd, i)
+		wantQ := partial(func(xs []float64) float64 { return kl(pd, xs) }, qd, i)
+		assertClose(t, "p grad", p[i].Grad, wantP, 1e-6)
+		assertClose(t, "q grad", q[i].Grad, wantQ, 1e-6)
+	}
+
+	// identical distributions have zero loss, and a constant target gets no gradient
+	p, q = values(pd...), values(pd...)
+	loss = KLDivLossTarget(p, q)
+	loss.Backward()
+	assertClose(t, "p == q", loss.Data, 0, 1e-12)
+	for i := range p {
+		assertClose(t, "target grad", p[i].Grad, 0, 0)
+		assertClose(t, "q grad at p == q", q[i].Grad, -1, 1e-12)
+	}
+
+	// a zero probability is clamped instead of producing NaN
+	loss = KLDivLoss(values(0.5, 0.5), values(1, 0))
+	if math.IsNaN(loss.Data) || math.IsInf(loss.Data, 0) {
+		t.Errorf("clamped loss = %v", loss.Data)
+	}
+}
+
+func TestSmoothL1Loss(t *testing.T) {
+	const beta = 2.0
+	for _, r := range []float64{0.3, -1.5, 2.5, -4} {
+		pred, target := NewValue(r, "p"), NewValue(0, "t")
+		loss := SmoothL1Loss(pred, target, beta)
+		loss.Backward()
+
+		want := NumGradDefault(func(x float64) float64 { return smoothL1(x, beta) }, r)
+		assertClose(t, "pred grad", pred.Grad, want, 1e-6)
+		assertClose(t, "target grad", target.Grad, -want, 1e-6)
+		// differs from Huber only by the 1/beta scale
+		assertClose(t, "huber relation", loss.Data, huber(r, beta)/beta, 1e-12)
+	}
+
+	const h = 1e-9
+	assertClose(t, "value continuity", smoothL1(beta-h, beta), smoothL1(beta+h, beta), 1e-8)
+	in, out := NewValue(beta-h, "p"), NewValue(beta+h, "p")
+	SmoothL1Loss(in, NewValue(0, "t"), beta).Backward()
+	SmoothL1Loss(out, NewValue(0, "t"), beta).Backward()
+	assertClose(t, "gradient continuity", in.Grad, out.Grad, 1e-8)
+}
+
+func TestBatchCrossEntropy(t *testing.T) {
+	xs := []float64{0.5, -1.2, 2}
+	targets := []int{0, 2, 1}
+	// logits [w*x, x, -x] share the weight w across the batch
+	logits := func(w *Value, x float64) []*Value {
+		xv := NewValue(x, "x")
+		return []*Value{Mul(w, xv), xv, Neg(xv)}
+	}
+
+	w := NewParam(0.8, "w")
+	batch := make([][]*Value, len(xs))
+	for i, x := range xs {
+		batch[i] = logits(w, x)
+	}
+	loss := BatchCrossEntropy(batch, targets)
+	loss.Backward()
+
+	meanLoss, meanGrad := 0.0, 0.0
+	for i, x := range xs {
+		wi := NewParam(0.8, "w")
+		l := CrossEntropy(logits(wi, x), targets[i])
+		l.Backward()
+		meanLoss += l.Data / float64(len(xs))
+		meanGrad += wi.Grad / float64(len(xs))
+	}
+	assertClose(t, "loss", loss.Data, meanLoss, 1e-12)
+	assertClose(t, "shared weight grad", w.Grad, meanGrad, 1e-12)
+
+	assertPanics(t, "length mismatch", func() { BatchCrossEntropy(batch, targets[:2]) })
+	assertPanics(t, "empty batch", func() { BatchCrossEntropy(nil, nil) })
+}
+
+func TestTripletLoss(t *testing.T) {
+	tests := []struct {
+		name string
+		neg  float64
+		want float64
+	}{
+		// d(a,p) = 1, d(a,n) = 0.25, margin 0.5
+		{"violated", 0.5, 1.25},
+		// d(a,n) = 9 clears the margin
+		{"satisfied", 3, 0},
+	}
+	for _, tt := range tests {
+		anchor, pos, neg := values(0, 0), values(1, 0), values(tt.neg, 0)
+		loss := TripletLoss(anchor, pos, neg, 0.5)
+		assertClose(t, tt.name, loss.Data, tt.want, 1e-12)
+		loss.Backward()
+
+		if tt.want == 0 {
+			for _, v := range append(append(anchor, pos...), neg...) {
+				if v.Grad != 0 {
+					t.Errorf("%s: got gradient %g, want none", tt.name, v.Grad)
+				}
+			}
+			continue
+		}
+		// descending pulls the positive toward the anchor and pushes the negative away
+		assertClose(t, "positive grad", pos[0].Grad, 2*(1-0), 1e-12)
+		assertClose(t, "negative grad", neg[0].Grad, -2*(tt.neg-0), 1e-12)
+		assertClose(t, "anchor grad", anchor[0].Grad, 2*(tt.neg-1), 1e-12)
+	}
+}
+
+func TestBCEWithLogitsLoss(t *testing.T) {
+	naive := func(z, t float64) float64 {
+		p := 1 / (1 + math.Exp(-z))
+		return -t*math.Log(p) - (1-t)*math.Log(1-p)
+	}
+	for _, z := range []float64{-4, -0.5, 0, 1.3, 6} {
+		for _, target := range []float64{0, 0.3, 1} {
+			zv := NewValue(z, "z")
+			loss := BCEWithLogitsLoss(zv, NewValue(target, "t"))
+			assertClose(t, "data", loss.Data, naive(z, target), 1e-9)
+
+			loss.Backward()
+			assertClose(t, "sigmoid(z) - t", zv.Grad, 1/(1+math.Exp(-z))-target, 1e-12)
+			f := func(z float64) float64 { return naive(z, target) }
+			assertClose(t, "numGrad", zv.Grad, NumGradDefault(f, z), 1e-6)
+		}
+	}
+
+	// where the naive form hits log(0) the stable one stays finite and linear
+	tests := []struct{ z, t, data, grad float64 }{
+		{1000, 0, 1000, 1},
+		{-1000, 1, 1000, -1},
+		{1000, 1, 0, 0},
+		{-1000, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		zv := NewValue(tt.z, "z")
+		loss := BCEWithLogitsLoss(zv, NewValue(tt.t, "t"))
+		loss.Backward()
+		assertClose(t, "large logit data", loss.Data, tt.data, 1e-9)
+		assertClose(t, "large logit grad", zv.Grad, tt.grad, 1e-12)
+	}
+}
@@ -3,17 +3,100 @@ package main
 import (
 	"fmt"
 	"math"
+	"strings"
+	"sync/atomic"
 )
 
+// Kind of operation that produced a node, used to recompute the forward pass
+// and to inspect the structure of a graph
+type OpKind int
+
+const (
+	OpLeaf OpKind = iota
+	OpAdd
+	OpMul
+	OpTanh
+	OpProd
+	OpMax
+	OpHuber
+	OpKLDiv
+	OpPow
+	OpSmoothL1
+	OpCheckpoint
+	OpExp
+	OpGreater
+	OpLess
+	OpSwish
+	OpMish
+	OpCustom
+	OpLog
+	OpAddN
+	OpReLU
+	OpNeg
+	OpAffine
+	OpBCEWithLogits
+	OpCosineSim
+)
+
+var opNames = map[OpKind]string{
+	OpLeaf:          "leaf",
+	OpAdd:           "add",
+	OpMul:           "mul",
+	OpTanh:          "tanh",
+	OpProd:          "prod",
+	OpMax:           "max",
+	OpHuber:         "huber",
+	OpKLDiv:         "kldiv",
+	OpPow:           "pow",
+	OpSmoothL1:      "smoothl1",
+	OpCheckpoint:    "checkpoint",
+	OpExp:           "exp",
+	OpGreater:       "greater",
+	OpLess:          "less",
+	OpSwish:         "swish",
+	OpMish:          "mish",
+	OpCustom:        "custom",
+	OpLog:           "log",
+	OpAddN:          "addn",
+	OpReLU:          "relu",
+	OpNeg:           "neg",
+	OpAffine:        "affine",
+	OpBCEWithLogits: "bcewithlogits",
+	OpCosineSim:     "cosinesim",
+}
+
+func (k OpKind) String() string {
+	if name, ok := opNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("OpKind(%d)", int(k))
+}
+
 type Value struct {
 	Data float64
 	Grad float64
 
+	Op   OpKind
+	args []float64
+
 	backward func()
 
 	parents []*Value
 
 	label string
+
+	// leaf created by NewParam that an optimizer should update
+	trainable bool
+
+	// leaf created by NewConst whose data never changes, which Fuse may fold
+	constant bool
+
+	// clock time at which the data was last written: when the node was built,
+	// by SetData or by Recompute. A node older than one of its parents is stale
+	version uint64
+
+	// called after Recompute or a compiled graph updates the data
+	hooks []func(*Value)
 }
 
 // Constructor
@@ -22,6 +105,7 @@ func NewValue(x float64, label string) *Value {
 		Data:    x,
 		label:   label,
 		parents: []*Value{},
+		version: tick(),
 	}
 
 	out.backward = func() {}
@@ -29,10 +113,162 @@ func NewValue(x float64, label string) *Value {
 	return out
 }
 
+// Constructor for a trainable parameter; values from NewValue are non-trainable inputs
+func NewParam(x float64, label string) *Value {
+	out := NewValue(x, label)
+	out.trainable = true
+	return out
+}
+
+// Constructor for a fixed constant such as a literal coefficient. Unlike an
+// input from NewValue its data is not expected to change between passes
+func NewConst(x float64, label string) *Value {
+	out := NewValue(x, label)
+	out.constant = true
+	return out
+}
+
+func (v *Value) Trainable() bool {
+	return v.trainable
+}
+
+func (v *Value) Constant() bool {
+	return v.constant
+}
+
+// Trainable leaves reachable from v, in topological order
+func (v *Value) Parameters() []*Value {
+	params := []*Value{}
+	v.WalkTopo(func(n *Value) {
+		if n.trainable {
+			params = append(params, n)
+		}
+	})
+	return params
+}
+
+// Trainable leaves reachable from root, in topological order so optimizer
+// state keyed on them stays stable; shared leaves appear once. Inputs from
+// NewValue and constants from NewConst are left out, so the result can be
+// passed straight to an optimizer
+func CollectParams(root *Value) []*Value {
+	return root.Parameters()
+}
+
+func (v *Value) Item() float64 {
+	return v.Data
+}
+
+// Global write clock, shared by all graphs and safe to advance concurrently
+var clock uint64
+
+func tick() uint64 {
+	return atomic.AddUint64(&clock, 1)
+}
+
+// Write the data of a node. Nodes computed from it before the write are stale,
+// so a Backward through them fails until Recompute has brought them up to date;
+// graphs built on it afterwards are unaffected
+func (v *Value) SetData(x float64) {
+	v.Data = x
+	v.version = tick()
+}
+
+// First node in the order written after a node computed from it, or nil
+func staleNode(order []*Value) *Value {
+	for _, n := range order {
+		for _, parent := range n.parents {
+			if parent.version > n.version {
+				return parent
+			}
+		}
+	}
+	return nil
+}
+
+// Accessors for inspecting the graph
+func (v *Value) Label() string {
+	return v.label
+}
+
+func (v *Value) Parents() []*Value {
+	return v.parents
+}
+
+// Whether ops record their parents and backward closures
+var gradEnabled = true
+
+// Enable or disable gradient tracking for the ops built afterwards, returning a
+// closure that restores the previous state, e.g. defer SetGradEnabled(false)()
+func SetGradEnabled(enabled bool) (restore func()) {
+	checkNotParallel("SetGradEnabled")
+	prev := gradEnabled
+	gradEnabled = enabled
+	return func() {
+		checkNotParallel("SetGradEnabled")
+		gradEnabled = prev
+	}
+}
+
+// Whether ops check their output for NaN/Inf as soon as it is computed
+var debugForward = false
+
+// Enable or disable the forward NaN/Inf guard, returning a closure that
+// restores the previous state
+func SetDebugForward(enabled bool) (restore func()) {
+	checkNotParallel("SetDebugForward")
+	prev := debugForward
+	debugForward = enabled
+	return func() {
+		checkNotParallel("SetDebugForward")
+		debugForward = prev
+	}
+}
+
+// Panic naming the node if debugForward is on and its data is NaN or Inf, so the
+// first bad op is reported rather than a downstream symptom
+func checkForward(v *Value) {
+	if debugForward && (math.IsNaN(v.Data) || math.IsInf(v.Data, 0)) {
+		panic(fmt.Sprintf("forward: %v node %q produced %v", v.Op, v.label, v.Data))
+	}
+}
+
+// Detach the output of an op from the graph when gradient tracking is disabled
+func track(out *Value) *Value {
+	out.version = tick()
+	checkForward(out)
+	if !gradEnabled {
+		out.Op = OpLeaf
+		out.args = nil
+		out.parents = []*Value{}
+		out.backward = func() {}
+	}
+	return out
+}
+
+// User-defined op: the backward closure receives the output node so it can
+// read out.Grad and push gradient into the parents. Like the built-in ops it
+// should read data through the nodes rather than capturing it up front
+func NewOp(data float64, parents []*Value, backward func(out *Value), label string) *Value {
+	out := &Value{
+		Data:    data,
+		Op:      OpCustom,
+		parents: append([]*Value{}, parents...),
+		label:   label,
+	}
+
+	out.backward = func() {
+		backward(out)
+	}
+
+	return track(out)
+}
+
 // Ops
 func Add(a, b *Value) *Value {
 	out := &Value{
 		Data:    a.Data + b.Data,
+		Op:      OpAdd,
 		parents: []*Value{a, b},
 		label:   fmt.Sprintf("(%s + %s)", a.label, b.label),
 	}
@@ -42,12 +278,39 @@ func Add(a, b *Value) *Value {
 		b.Grad += out.Grad
 	}
 
-	return out
+	return track(out)
+}
+
+// Sum of any number of values as a single node, keeping the graph shallow
+func AddN(vs ...*Value) *Value {
+	data := 0.0
+	labels := make([]string, len(vs))
+	for i, v := range vs {
+		data += v.Data
+		labels[i] = v.label
+	}
+
+	out := &Value{
+		Data:    data,
+		Op:      OpAddN,
+		parents: append([]*Value{}, vs...),
+		label:   fmt.Sprintf("(%s)", strings.Join(labels, " + ")),
+	}
+
+	out.backward = func() {
+		// a value passed several times gets gradient once per occurrence
+		for _, v := range vs {
+			v.Grad += out.Grad
+		}
+	}
+
+	return track(out)
 }
 
 func Mul(a, b *Value) *Value {
 	out := &Value{
 		Data:    a.Data * b.Data,
+		Op:      OpMul,
 		parents: []*Value{a, b},
 		label:   fmt.Sprintf("(%s * %s)", a.label, b.label),
 	}
@@ -57,12 +320,13 @@ func Mul(a, b *Value) *Value {
 		b.Grad += out.Grad * a.Data
 	}
 
-	return out
+	return track(out)
 }
 
 func Tanh(a *Value) *Value {
 	out := &Value{
 		Data:    math.Tanh(a.Data),
+		Op:      OpTanh,
 		parents: []*Value{a},
 		label:   fmt.Sprintf("tanh(%s)", a.label),
 	}
@@ -71,7 +335,343 @@ func Tanh(a *Value) *Value {
 		a.Grad += out.Grad * (1 - out.Data*out.Data)
 	}
 
-	return out
+	return track(out)
+}
+
+func Exp(a *Value) *Value {
+	out := &Value{
+		Data:    math.Exp(a.Data),
+		Op:      OpExp,
+		parents: []*Value{a},
+		label:   fmt.Sprintf("exp(%s)", a.label),
+	}
+
+	out.backward = func() {
+		a.Grad += out.Grad * out.Data
+	}
+
+	return track(out)
+}
+
+func Neg(a *Value) *Value {
+	out := &Value{
+		Data:    -a.Data,
+		Op:      OpNeg,
+		parents: []*Value{a},
+		label:   fmt.Sprintf("-%s", a.label),
+	}
+
+	out.backward = func() {
+		a.Grad -= out.Grad
+	}
+
+	return track(out)
+}
+
+func Sub(a, b *Value) *Value {
+	return Add(a, Neg(b))
+}
+
+func Log(a *Value) *Value {
+	out := &Value{
+		Data:    math.Log(a.Data),
+		Op:      OpLog,
+		parents: []*Value{a},
+		label:   fmt.Sprintf("log(%s)", a.label),
+	}
+
+	out.backward = func() {
+		a.Grad += out.Grad / a.Data
+	}
+
+	return track(out)
+}
+
+func ReLU(a *Value) *Value {
+	out := &Value{
+		Data:    math.Max(a.Data, 0),
+		Op:      OpReLU,
+		parents: []*Value{a},
+		label:   fmt.Sprintf("relu(%s)", a.label),
+	}
+
+	out.backward = func() {
+		if a.Data > 0 {
+			a.Grad += out.Grad
+		}
+	}
+
+	return track(out)
+}
+
+// Logistic sigmoid that never exponentiates a large positive number
+func sigmoid(x float64) float64 {
+	if x >= 0 {
+		return 1 / (1 + math.Exp(-x))
+	}
+	e := math.Exp(x)
+	return e / (1 + e)
+}
+
+// Swish / SiLU activation x * sigmoid(x)
+func Swish(a *Value) *Value {
+	out := &Value{
+		Data:    a.Data * sigmoid(a.Data),
+		Op:      OpSwish,
+		parents: []*Value{a},
+		label:   fmt.Sprintf("swish(%s)", a.label),
+	}
+
+	out.backward = func() {
+		s := sigmoid(a.Data)
+		a.Grad += out.Grad * (s + a.Data*s*(1-s))
+	}
+
+	return track(out)
+}
+
+// Softplus log(1 + exp(x)) written so exp never overflows
+func softplus(x float64) float64 {
+	return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))
+}
+
+// Mish activation x * tanh(softplus(x)), with a hand-derived gradient:
+// tanh(sp(x)) + x * (1 - tanh(sp(x))^2) * sigmoid(x)
+func Mish(a *Value) *Value {
+	out := &Value{
+		Data:    a.Data * math.Tanh(softplus(a.Data)),
+		Op:      OpMish,
+		parents: []*Value{a},
+		label:   fmt.Sprintf("mish(%s)", a.label),
+	}
+
+	out.backward = func() {
+		t := math.Tanh(softplus(a.Data))
+		a.Grad += out.Grad * (t + a.Data*(1-t*t)*sigmoid(a.Data))
+	}
+
+	return track(out)
+}
+
+// Tanh built from existing ops as (exp(2x)-1)/(exp(2x)+1), so it can share the
+// exp subexpression with other exp-based ops
+func TanhFromExp(a *Value) *Value {
+	e := Exp(Mul(NewConst(2, "2"), a))
+	num := Add(e, NewConst(-1, "-1"))
+	den := Add(e, NewConst(1, "1"))
+	return Mul(num, Pow(den, -1))
+}
+
+// scale * a + shift as a single node
+func Affine(a *Value, scale, shift float64) *Value {
+	out := &Value{
+		Data:    scale*a.Data + shift,
+		Op:      OpAffine,
+		args:    []float64{scale, shift},
+		parents: []*Value{a},
+		label:   fmt.Sprintf("(%g * %s + %g)", scale, a.label, shift),
+	}
+
+	out.backward = func() {
+		a.Grad += out.Grad * scale
+	}
+
+	return track(out)
+}
+
+func Pow(a *Value, p float64) *Value {
+	out := &Value{
+		Data:    math.Pow(a.Data, p),
+		Op:      OpPow,
+		args:    []float64{p},
+		parents: []*Value{a},
+		label:   fmt.Sprintf("(%s ^ %g)", a.label, p),
+	}
+
+	out.backward = func() {
+		a.Grad += out.Grad * p * math.Pow(a.Data, p-1)
+	}
+
+	return track(out)
+}
+
+// Product of all the values; the gradient of each element is the product of the others
+func Prod(vs []*Value) *Value {
+	data := 1.0
+	labels := make([]string, len(vs))
+	for i, v := range vs {
+		data *= v.Data
+		labels[i] = v.label
+	}
+
+	out := &Value{
+		Data:    data,
+		Op:      OpProd,
+		parents: append([]*Value{}, vs...),
+		label:   fmt.Sprintf("prod(%s)", strings.Join(labels, ", ")),
+	}
+
+	out.backward = func() {
+		zeros := 0
+		for _, v := range vs {
+			if v.Data == 0 {
+				zeros++
+			}
+		}
+		// product / element is only safe when nothing is zero, otherwise
+		// compute the product excluding each element directly
+		if zeros == 0 {
+			for _, v := range vs {
+				v.Grad += out.Grad * (out.Data / v.Data)
+			}
+			return
+		}
+		for i, v := range vs {
+			others := 1.0
+			for j, w := range vs {
+				if j != i {
+					others *= w.Data
+				}
+			}
+			v.Grad += out.Grad * others
+		}
+	}
+
+	return track(out)
+}
+
+// Index of the largest value, ties resolve to the lowest index
+func argmax(vs []*Value) int {
+	best := 0
+	for i, v := range vs {
+		if v.Data > vs[best].Data {
+			best = i
+		}
+	}
+	return best
+}
+
+// Maximum of the values and the winning index; only the winner receives gradient
+func MaxSlice(vs []*Value) (*Value, int) {
+	if len(vs) == 0 {
+		panic("MaxSlice: empty slice")
+	}
+
+	labels := make([]string, len(vs))
+	for i, v := range vs {
+		labels[i] = v.label
+	}
+
+	best := argmax(vs)
+	out := &Value{
+		Data:    vs[best].Data,
+		Op:      OpMax,
+		parents: append([]*Value{}, vs...),
+		label:   fmt.Sprintf("max(%s)", strings.Join(labels, ", ")),
+	}
+
+	out.backward = func() {
+		vs[argmax(vs)].Grad += out.Grad
+	}
+
+	return track(out), best
+}
+
+func mask(cond bool) float64 {
+	if cond {
+		return 1.0
+	}
+	return 0.0
+}
+
+// 1.0 when a > b else 0.0; comparisons are not differentiable so neither
+// operand receives gradient
+func GreaterThan(a, b *Value) *Value {
+	out := &Value{
+		Data:    mask(a.Data > b.Data),
+		Op:      OpGreater,
+		parents: []*Value{a, b},
+		label:   fmt.Sprintf("(%s > %s)", a.label, b.label),
+	}
+
+	out.backward = func() {}
+
+	return track(out)
+}
+
+// 1.0 when a < b else 0.0, with no gradient to either operand
+func LessThan(a, b *Value) *Value {
+	out := &Value{
+		Data:    mask(a.Data < b.Data),
+		Op:      OpLess,
+		parents: []*Value{a, b},
+		label:   fmt.Sprintf("(%s < %s)", a.label, b.label),
+	}
+
+	out.backward = func() {}
+
+	return track(out)
+}
+
+// Norm products below this are treated as degenerate by CosineSim
+const cosineEps = 1e-8
+
+// dot(a,b) and the norms of a and b
+func cosineParts(a, b []*Value) (dot, na, nb float64) {
+	for i := range a {
+		dot += a[i].Data * b[i].Data
+		na += a[i].Data * a[i].Data
+		nb += b[i].Data * b[i].Data
+	}
+	return dot, math.Sqrt(na), math.Sqrt(nb)
+}
+
+// Cosine similarity dot(a,b)/max(||a|| * ||b||, eps). When the norm product is
+// clamped (e.g. a zero vector) the similarity gets no gradient, instead of one
+// scaled by 1/eps
+func CosineSim(a, b []*Value) *Value {
+	if len(a) != len(b) || len(a) == 0 {
+		panic(fmt.Sprintf("CosineSim: length mismatch %d != %d", len(a), len(b)))
+	}
+
+	dot, na, nb := cosineParts(a, b)
+	out := &Value{
+		Data:    dot / math.Max(na*nb, cosineEps),
+		Op:      OpCosineSim,
+		parents: append(append([]*Value{}, a...), b...),
+		label:   "cos(a, b)",
+	}
+
+	out.backward = func() {
+		dot, na, nb := cosineParts(a, b)
+		norms := na * nb
+		if norms < cosineEps {
+			return
+		}
+		sim := dot / norms
+		for i := range a {
+			// the normalization couples every element into every gradient
+			ga := b[i].Data/norms - sim*a[i].Data/(na*na)
+			gb := a[i].Data/norms - sim*b[i].Data/(nb*nb)
+			a[i].Grad += out.Grad * ga
+			b[i].Grad += out.Grad * gb
+		}
+	}
+
+	return track(out)
+}
+
+// Squared Euclidean distance sum((a_i - b_i)^2)
+func SquaredDistance(a, b []*Value) *Value {
+	if len(a) != len(b) {
+		panic(fmt.Sprintf("SquaredDistance: length mismatch %d != %d", len(a), len(b)))
+	}
+
+	terms := make([]*Value, len(a))
+	for i := range a {
+		terms[i] = Pow(Sub(a[i], b[i]), 2)
+	}
+	return AddN(terms...)
 }
 
 // Zero out the gradient of the node and all its parents to clear the previous backward pass
@@ -91,9 +691,24 @@ func (v *Value) ZeroGrad() {
 	dfs(v)
 }
 
-// Topological sort of the graph
+// Topological sort of the graph. The order depends only on the order of each
+// node's parents (the visited map is never iterated), so it is the same on
+// every run and the backward pass accumulates gradients reproducibly
 func TopoSort(v *Value) []*Value {
 	order := []*Value{}
+	v.WalkTopo(func(n *Value) {
+		order = append(order, n)
+	})
+	return order
+}
+
+// Visit every node once in the same order as TopoSort, without building the slice
+func (v *Value) WalkTopo(fn func(*Value)) {
+	walkTopo([]*Value{v}, fn)
+}
+
+// Visit every node reachable from any of the roots once, parents first
+func walkTopo(roots []*Value, fn func(*Value)) {
 	visited := map[*Value]bool{}
 	var dfs func(v *Value)
 	dfs = func(v *Value) {
@@ -104,16 +719,34 @@ func TopoSort(v *Value) []*Value {
 		for _, parent := range v.parents {
 			dfs(parent)
 		}
-		order = append(order, v)
+		fn(v)
+	}
+	for _, root := range roots {
+		dfs(root)
+	}
+}
+
+// Visit every node once in backward order, from the root down to the leaves
+func (v *Value) WalkReverseTopo(fn func(*Value)) {
+	// the reverse order is only known once the whole graph has been seen
+	order := TopoSort(v)
+	for i := len(order) - 1; i >= 0; i-- {
+		fn(order[i])
 	}
-	dfs(v)
-	return order
 }
 
+// Number of backward passes started, so an op can tell whether a gradient was
+// set in the current pass or left over from an earlier one
+var backwardPasses uint64
+
 // Backward pass of the graph
 func (v *Value) Backward() {
 	order := TopoSort(v)
+	if n := staleNode(order); n != nil {
+		panic(fmt.Sprintf("backward: data of %q changed by SetData, call Recompute first", n.label))
+	}
 
+	atomic.AddUint64(&backwardPasses, 1)
 	v.ZeroGrad()
 	v.Grad = 1.0
 	for i := len(order) - 1; i >= 0; i-- {
@@ -121,17 +754,189 @@ func (v *Value) Backward() {
 	}
 }
 
-// Numerical gradient of a function
-func numGrad(f func(float64) float64, x float64) float64 {
-	eps := 1e-6
+// Node at which a cycle is reachable from v, or nil if the graph is a DAG
+func findCycle(v *Value) *Value {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := map[*Value]int{}
+	var dfs func(v *Value) *Value
+	dfs = func(v *Value) *Value {
+		switch state[v] {
+		case inProgress:
+			return v
+		case done:
+			return nil
+		}
+		state[v] = inProgress
+		for _, parent := range v.parents {
+			if n := dfs(parent); n != nil {
+				return n
+			}
+		}
+		state[v] = done
+		return nil
+	}
+	return dfs(v)
+}
+
+// Backward pass that returns an error instead of panicking on a malformed
+// graph, naming the node where the failure occurred
+func (v *Value) BackwardSafe() (err error) {
+	if n := findCycle(v); n != nil {
+		return fmt.Errorf("backward: cycle through node %q", n.label)
+	}
+
+	var current *Value
+	defer func() {
+		if r := recover(); r != nil {
+			if current == nil {
+				err = fmt.Errorf("backward: %v", r)
+				return
+			}
+			err = fmt.Errorf("backward: node %q: %v", current.label, r)
+		}
+	}()
+
+	order := TopoSort(v)
+	if n := staleNode(order); n != nil {
+		return fmt.Errorf("backward: data of %q changed by SetData, call Recompute first", n.label)
+	}
+
+	atomic.AddUint64(&backwardPasses, 1)
+	v.ZeroGrad()
+	v.Grad = 1.0
+	for i := len(order) - 1; i >= 0; i-- {
+		current = order[i]
+		current.backward()
+	}
+	return nil
+}
+
+// Recompute the data of a single node from its parents' current data
+func (v *Value) recompute() {
+	switch v.Op {
+	case OpLeaf:
+	case OpAdd:
+		v.Data = v.parents[0].Data + v.parents[1].Data
+	case OpMul:
+		v.Data = v.parents[0].Data * v.parents[1].Data
+	case OpTanh:
+		v.Data = math.Tanh(v.parents[0].Data)
+	case OpProd:
+		v.Data = 1.0
+		for _, parent := range v.parents {
+			v.Data *= parent.Data
+		}
+	case OpMax:
+		v.Data = v.parents[argmax(v.parents)].Data
+	case OpHuber:
+		v.Data = huber(v.parents[0].Data-v.parents[1].Data, v.args[0])
+	case OpPow:
+		v.Data = math.Pow(v.parents[0].Data, v.args[0])
+	case OpSmoothL1:
+		v.Data = smoothL1(v.parents[0].Data-v.parents[1].Data, v.args[0])
+	case OpKLDiv:
+		n := len(v.parents) / 2
+		v.Data = klDiv(v.parents[:n], v.parents[n:])
+	case OpCheckpoint, OpCustom:
+		// these carry their own forward logic, which is not stored on the node
+		panic(fmt.Sprintf("recompute: %v nodes must be rebuilt", v.Op))
+	case OpExp:
+		v.Data = math.Exp(v.parents[0].Data)
+	case OpGreater:
+		v.Data = mask(v.parents[0].Data > v.parents[1].Data)
+	case OpLess:
+		v.Data = mask(v.parents[0].Data < v.parents[1].Data)
+	case OpSwish:
+		v.Data = v.parents[0].Data * sigmoid(v.parents[0].Data)
+	case OpMish:
+		v.Data = v.parents[0].Data * math.Tanh(softplus(v.parents[0].Data))
+	case OpLog:
+		v.Data = math.Log(v.parents[0].Data)
+	case OpAddN:
+		v.Data = 0
+		for _, parent := range v.parents {
+			v.Data += parent.Data
+		}
+	case OpReLU:
+		v.Data = math.Max(v.parents[0].Data, 0)
+	case OpNeg:
+		v.Data = -v.parents[0].Data
+	case OpAffine:
+		v.Data = v.args[0]*v.parents[0].Data + v.args[1]
+	case OpBCEWithLogits:
+		v.Data = bceWithLogits(v.parents[0].Data, v.parents[1].Data)
+	case OpCosineSim:
+		n := len(v.parents) / 2
+		dot, na, nb := cosineParts(v.parents[:n], v.parents[n:])
+		v.Data = dot / math.Max(na*nb, cosineEps)
+	default:
+		panic(fmt.Sprintf("recompute: unknown op %v", v.Op))
+	}
+}
+
+// Re-evaluate the forward pass through the existing graph, so that a graph
+// can be built once and reused after changing the data of its leaves. Backward
+// closures never capture data at construction time, they read it through the
+// node pointers, so a backward pass after Recompute uses the new values
+func (v *Value) Recompute() {
+	recomputeAll(TopoSort(v))
+}
+
+func recomputeAll(order []*Value) {
+	for _, node := range order {
+		node.recompute()
+		// a leaf's data only changes through SetData, so re-stamping it would make
+		// other graphs built on the same leaf look stale
+		if node.Op != OpLeaf {
+			node.version = tick()
+		}
+		checkForward(node)
+		for _, hook := range node.hooks {
+			hook(node)
+		}
+	}
+}
+
+// Register a hook called with the node every time its data is recomputed
+func (v *Value) OnForward(fn func(*Value)) {
+	v.hooks = append(v.hooks, fn)
+}
+
+// Freeze the graph into a function of its inputs: each call writes xs into the
+// input leaves, recomputes the forward pass in place and returns the root's data
+func Compile(root *Value, inputs []*Value) func(xs []float64) float64 {
+	order := TopoSort(root)
+	return func(xs []float64) float64 {
+		if len(xs) != len(inputs) {
+			panic(fmt.Sprintf("compiled graph: expected %d inputs, got %d", len(inputs), len(xs)))
+		}
+		for i, in := range inputs {
+			in.SetData(xs[i])
+		}
+		recomputeAll(order)
+		return root.Data
+	}
+}
+
+// Numerical gradient of a function by central difference with step eps
+func NumGrad(f func(float64) float64, x, eps float64) float64 {
 	return (f(x+eps) - f(x-eps)) / (2 * eps)
 }
 
+// Numerical gradient with the default step
+func NumGradDefault(f func(float64) float64, x float64) float64 {
+	return NumGrad(f, x, 1e-6)
+}
+
 func main() {
 	// build graph: x -> y=2x -> z=y+3 -> f=tanh(z)
 	x := NewValue(1.0, "x")
-	two := NewValue(2.0, "2")
-	three := NewValue(3.0, "3")
+	two := NewConst(2.0, "2")
+	three := NewConst(3.0, "3")
 
 	y := Mul(two, x)
 	z := Add(y, three)
@@ -143,7 +948,7 @@ func main() {
 
 	// compare to numerical grad
 	got := x.Grad
-	want := numGrad(func(xx float64) float64 {
+	want := NumGradDefault(func(xx float64) float64 {
 		return math.Tanh(2*xx + 3)
 	}, 1.0)
 
@@ -0,0 +1,692 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func assertClose(t *testing.T, name string, got, want, tol float64) {
+	t.Helper()
+	if math.Abs(got-want) > tol {
+		t.Errorf("%s: got %.10g, want %.10g", name, got, want)
+	}
+}
+
+// Leaves holding the given data
+func values(xs ...float64) []*Value {
+	vs := make([]*Value, len(xs))
+	for i, x := range xs {
+		vs[i] = NewValue(x, "v")
+	}
+	return vs
+}
+
+// Numerical gradient of f with respect to element i of xs
+func partial(f func([]float64) float64, xs []float64, i int) float64 {
+	return NumGradDefault(func(x float64) float64 {
+		ys := append([]float64{}, xs...)
+		ys[i] = x
+		return f(ys)
+	}, xs[i])
+}
+
+func TestProd(t *testing.T) {
+	prod := func(xs []float64) float64 {
+		p := 1.0
+		for _, x := range xs {
+			p *= x
+		}
+		return p
+	}
+
+	tests := []struct {
+		name string
+		xs   []float64
+	}{
+		{"nonzero", []float64{2, -3, 0.5}},
+		{"single zero", []float64{2, 0, 5}},
+		{"two zeros", []float64{0, 3, 0}},
+	}
+	for _, tt := range tests {
+		vs := values(tt.xs...)
+		out := Prod(vs)
+		out.Backward()
+
+		assertClose(t, tt.name+" data", out.Data, prod(tt.xs), 1e-12)
+		for i, v := range vs {
+			if math.IsNaN(v.Grad) || math.IsInf(v.Grad, 0) {
+				t.Fatalf("%s: grad[%d] = %v", tt.name, i, v.Grad)
+			}
+			assertClose(t, tt.name+" grad", v.Grad, partial(prod, tt.xs, i), 1e-6)
+		}
+	}
+
+	// with one zero only the zero element gets the product of the others
+	vs := values(2, 0, 5)
+	Prod(vs).Backward()
+	assertClose(t, "zero element grad", vs[1].Grad, 10, 0)
+	assertClose(t, "nonzero element grad", vs[0].Grad, 0, 0)
+}
+
+func TestMaxSlice(t *testing.T) {
+	tests := []struct {
+		name  string
+		xs    []float64
+		index int
+	}{
+		{"strict max", []float64{1, 4, 3}, 1},
+		{"tie picks lowest index", []float64{2, 5, 5, 1}, 1},
+		{"all equal", []float64{7, 7, 7}, 0},
+	}
+	for _, tt := range tests {
+		vs := values(tt.xs...)
+		out, idx := MaxSlice(vs)
+		out.Backward()
+
+		if idx != tt.index {
+			t.Errorf("%s: index %d, want %d", tt.name, idx, tt.index)
+		}
+		assertClose(t, tt.name+" data", out.Data, tt.xs[tt.index], 0)
+		for i, v := range vs {
+			want := 0.0
+			if i == tt.index {
+				want = 1
+			}
+			assertClose(t, tt.name+" grad", v.Grad, want, 0)
+		}
+	}
+}
+
+// tanh(w*x + b) * x with both products sharing x
+func sampleGraph(x, w, b float64) (*Value, *Value) {
+	xv := NewValue(x, "x")
+	out := Mul(Tanh(Add(Mul(NewValue(w, "w"), xv), NewValue(b, "b"))), xv)
+	return out, xv
+}
+
+func TestRecompute(t *testing.T) {
+	out, x := sampleGraph(0.5, 2, 3)
+	for _, data := range []float64{-1.3, 0, 2.2} {
+		x.Data = data
+		out.Recompute()
+
+		want, _ := sampleGraph(data, 2, 3)
+		assertClose(t, "recomputed data", out.Data, want.Data, 1e-12)
+	}
+}
+
+func TestCosineSim(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		{"identical", []float64{1, 2, 3}, []float64{1, 2, 3}, 1},
+		{"opposite", []float64{1, 2, 3}, []float64{-1, -2, -3}, -1},
+		{"orthogonal", []float64{1, 0}, []float64{0, 4}, 0},
+		{"zero vector", []float64{0, 0}, []float64{1, 2}, 0},
+	}
+	for _, tt := range tests {
+		assertClose(t, tt.name, CosineSim(values(tt.a...), values(tt.b...)).Data, tt.want, 1e-12)
+	}
+
+	cos := func(a, b []float64) float64 {
+		dot, na, nb := 0.0, 0.0, 0.0
+		for i := range a {
+			dot += a[i] * b[i]
+			na += a[i] * a[i]
+			nb += b[i] * b[i]
+		}
+		return dot / math.Sqrt(na*nb)
+	}
+	ad, bd := []float64{0.5, -1, 2}, []float64{1, 0.3, -0.7}
+	a, b := values(ad...), values(bd...)
+	CosineSim(a, b).Backward()
+	for i := range ad {
+		wantA := partial(func(xs []float64) float64 { return cos(xs, bd) }, ad, i)
+		wantB := partial(func(xs []float64) float64 { return cos(ad, xs) }, bd, i)
+		assertClose(t, "a grad", a[i].Grad, wantA, 1e-6)
+		assertClose(t, "b grad", b[i].Grad, wantB, 1e-6)
+	}
+
+	// a zero vector gets no gradient rather than one blown up by 1/eps
+	zero := values(0, 0)
+	CosineSim(zero, values(1, 2)).Backward()
+	for _, v := range zero {
+		assertClose(t, "zero vector grad", v.Grad, 0, 0)
+	}
+}
+
+func TestSetGradEnabled(t *testing.T) {
+	x := NewValue(2, "x")
+
+	restoreOuter := SetGradEnabled(false)
+	detached := Mul(x, x)
+	restoreInner := SetGradEnabled(true)
+	tracked := Mul(x, x)
+	restoreInner()
+	if gradEnabled {
+		t.Fatal("inner restore should return to the disabled outer state")
+	}
+	restoreOuter()
+	if !gradEnabled {
+		t.Fatal("outer restore should re-enable tracking")
+	}
+
+	tracked.Backward()
+	assertClose(t, "tracked grad", x.Grad, 4, 0)
+
+	x.Grad = 0
+	detached.Backward()
+	if detached.Op != OpLeaf || len(detached.parents) != 0 {
+		t.Errorf("op built while disabled should be a leaf, got %v with %d parents", detached.Op, len(detached.parents))
+	}
+	assertClose(t, "detached grad", x.Grad, 0, 0)
+	assertClose(t, "detached data", detached.Data, 4, 0)
+}
+
+func TestOpKinds(t *testing.T) {
+	a, b := NewValue(0.5, "a"), NewValue(2, "b")
+	maxOut, _ := MaxSlice([]*Value{a, b})
+	tests := []struct {
+		v    *Value
+		want OpKind
+	}{
+		{NewValue(1, "x"), OpLeaf},
+		{NewParam(1, "w"), OpLeaf},
+		{Add(a, b), OpAdd},
+		{AddN(a, b, a), OpAddN},
+		{Mul(a, b), OpMul},
+		{Tanh(a), OpTanh},
+		{Exp(a), OpExp},
+		{Neg(a), OpNeg},
+		{Log(b), OpLog},
+		{ReLU(a), OpReLU},
+		{Swish(a), OpSwish},
+		{Mish(a), OpMish},
+		{Affine(a, 2, 1), OpAffine},
+		{Pow(a, 3), OpPow},
+		{Prod([]*Value{a, b}), OpProd},
+		{maxOut, OpMax},
+		{GreaterThan(a, b), OpGreater},
+		{LessThan(a, b), OpLess},
+		{CosineSim([]*Value{a}, []*Value{b}), OpCosineSim},
+		{HuberLoss(a, b, 1), OpHuber},
+		{SmoothL1Loss(a, b, 1), OpSmoothL1},
+		{KLDivLoss([]*Value{a}, []*Value{b}), OpKLDiv},
+		{BCEWithLogitsLoss(a, b), OpBCEWithLogits},
+		{NewOp(1, []*Value{a}, func(*Value) {}, "custom"), OpCustom},
+		{Checkpoint(func(in []*Value) []*Value { return in }, []*Value{a})[0], OpCheckpoint},
+	}
+
+	covered := map[OpKind]bool{}
+	for _, tt := range tests {
+		if tt.v.Op != tt.want {
+			t.Errorf("%s: op %v, want %v", tt.v.label, tt.v.Op, tt.want)
+		}
+		covered[tt.want] = true
+	}
+	for kind, name := range opNames {
+		if !covered[kind] {
+			t.Errorf("op kind %s has no constructor test", name)
+		}
+		if kind.String() != name {
+			t.Errorf("String() = %q, want %q", kind.String(), name)
+		}
+	}
+	if got := OpKind(-1).String(); got != "OpKind(-1)" {
+		t.Errorf("unknown kind: got %q", got)
+	}
+}
+
+func TestTanhFromExp(t *testing.T) {
+	for _, x := range []float64{-5, -2, -0.5, 0, 0.3, 1, 4} {
+		a, b := NewValue(x, "a"), NewValue(x, "b")
+		got, want := TanhFromExp(a), Tanh(b)
+		assertClose(t, "data", got.Data, want.Data, 1e-12)
+
+		got.Backward()
+		want.Backward()
+		assertClose(t, "grad", a.Grad, b.Grad, 1e-12)
+	}
+}
+
+func TestBackwardSafe(t *testing.T) {
+	x := NewValue(0.5, "x")
+	out := Tanh(Mul(x, x))
+	if err := out.BackwardSafe(); err != nil {
+		t.Fatalf("happy path: %v", err)
+	}
+	assertClose(t, "happy path grad", x.Grad, (1-out.Data*out.Data)*2*0.5, 1e-12)
+
+	tests := []struct {
+		name  string
+		build func() *Value
+		want  string
+	}{
+		{"nil closure", func() *Value {
+			broken := NewOp(1, []*Value{NewValue(1, "a")}, nil, "broken")
+			return Tanh(broken)
+		}, `node "broken"`},
+		{"cycle", func() *Value {
+			a := NewValue(1, "a")
+			loop := Add(a, NewValue(2, "b"))
+			loop.label = "loop"
+			a.parents = []*Value{loop}
+			return Tanh(loop)
+		}, "cycle"},
+	}
+	for _, tt := range tests {
+		err := tt.build().BackwardSafe()
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q does not mention %s", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestComparisons(t *testing.T) {
+	tests := []struct {
+		a, b          float64
+		greater, less float64
+	}{
+		{1, 2, 0, 1},
+		{2, 2, 0, 0},
+		{3, 2, 1, 0},
+	}
+	for _, tt := range tests {
+		a, b := NewValue(tt.a, "a"), NewValue(tt.b, "b")
+		gt, lt := GreaterThan(a, b), LessThan(a, b)
+		if gt.Data != tt.greater || lt.Data != tt.less {
+			t.Errorf("%g vs %g: got > %g, < %g, want %g, %g", tt.a, tt.b, gt.Data, lt.Data, tt.greater, tt.less)
+		}
+
+		// the mask feeds a product, yet neither operand gets gradient through it
+		Mul(Add(gt, lt), a).Backward()
+		if b.Grad != 0 {
+			t.Errorf("%g vs %g: b grad %g, want 0", tt.a, tt.b, b.Grad)
+		}
+		if a.Grad != tt.greater+tt.less {
+			t.Errorf("%g vs %g: a grad %g, want only the product term %g", tt.a, tt.b, a.Grad, tt.greater+tt.less)
+		}
+	}
+}
+
+// x feeds two branches that join again, with the join reused downstream
+func diamond(x float64) (*Value, *Value) {
+	xv := NewValue(x, "x")
+	left, right := Tanh(xv), Exp(Mul(xv, NewValue(0.3, "k")))
+	join := Add(left, right)
+	return AddN(Mul(join, left), Mul(join, right), join), xv
+}
+
+func TestTopoSortDeterministic(t *testing.T) {
+	out, x := diamond(0.7)
+	first := TopoSort(out)
+	out.Backward()
+	grads := FlattenGrads(first)
+	xGrad := x.Grad
+
+	for run := 0; run < 200; run++ {
+		order := TopoSort(out)
+		if len(order) != len(first) {
+			t.Fatalf("run %d: %d nodes, want %d", run, len(order), len(first))
+		}
+		for i := range order {
+			if order[i] != first[i] {
+				t.Fatalf("run %d: node %d is %q, want %q", run, i, order[i].label, first[i].label)
+			}
+		}
+
+		out.Backward()
+		if x.Grad != xGrad {
+			t.Fatalf("run %d: x grad %v, want bit-identical %v", run, x.Grad, xGrad)
+		}
+		for i, g := range FlattenGrads(order) {
+			if g != grads[i] {
+				t.Fatalf("run %d: grad of %q is %v, want %v", run, order[i].label, g, grads[i])
+			}
+		}
+	}
+
+	// a freshly built copy sorts the same way
+	again, _ := diamond(0.7)
+	for i, n := range TopoSort(again) {
+		if n.Op != first[i].Op || n.label != first[i].label {
+			t.Fatalf("rebuilt graph: node %d is %q, want %q", i, n.label, first[i].label)
+		}
+	}
+}
+
+func TestSwish(t *testing.T) {
+	swish := func(x float64) float64 { return x / (1 + math.Exp(-x)) }
+	for _, x := range []float64{-4, -1, -0.2, 0, 0.5, 3} {
+		a := NewValue(x, "a")
+		out := Swish(a)
+		assertClose(t, "data", out.Data, swish(x), 1e-12)
+		out.Backward()
+		assertClose(t, "grad", a.Grad, NumGradDefault(swish, x), 1e-6)
+	}
+
+	// extreme inputs: identity far right, zero far left, never NaN
+	tests := []struct{ x, data, grad float64 }{
+		{800, 800, 1},
+		{-800, 0, 0},
+	}
+	for _, tt := range tests {
+		a := NewValue(tt.x, "a")
+		out := Swish(a)
+		out.Backward()
+		assertClose(t, "extreme data", out.Data, tt.data, 1e-9)
+		assertClose(t, "extreme grad", a.Grad, tt.grad, 1e-9)
+	}
+}
+
+func TestMish(t *testing.T) {
+	mish := func(x float64) float64 { return x * math.Tanh(math.Log(1+math.Exp(x))) }
+	tests := []struct {
+		name string
+		xs   []float64
+	}{
+		{"positive", []float64{0.5, 2, 6}},
+		{"negative", []float64{-0.5, -2, -6}},
+		{"near zero", []float64{-1e-3, 0, 1e-3}},
+	}
+	for _, tt := range tests {
+		for _, x := range tt.xs {
+			a := NewValue(x, "a")
+			out := Mish(a)
+			assertClose(t, tt.name+" data", out.Data, mish(x), 1e-12)
+			out.Backward()
+			assertClose(t, tt.name+" grad", a.Grad, NumGradDefault(mish, x), 1e-6)
+		}
+	}
+}
+
+// x^2 as a user-defined op
+func square(a *Value) *Value {
+	return NewOp(a.Data*a.Data, []*Value{a}, func(out *Value) {
+		a.Grad += out.Grad * 2 * a.Data
+	}, "square")
+}
+
+func TestNewOp(t *testing.T) {
+	for _, x := range []float64{-1.5, 0, 0.4, 3} {
+		a, b := NewValue(x, "a"), NewValue(x, "b")
+		got, want := Tanh(square(a)), Tanh(Pow(b, 2))
+		assertClose(t, "data", got.Data, want.Data, 1e-12)
+
+		got.Backward()
+		want.Backward()
+		assertClose(t, "grad vs pow", a.Grad, b.Grad, 1e-12)
+		f := func(x float64) float64 { return math.Tanh(x * x) }
+		assertClose(t, "grad vs numGrad", a.Grad, NumGradDefault(f, x), 1e-6)
+	}
+}
+
+func TestAddN(t *testing.T) {
+	a, b := values(1, -2, 3.5, 0.25), values(1, -2, 3.5, 0.25)
+	got := AddN(a...)
+	want := b[0]
+	for _, v := range b[1:] {
+		want = Add(want, v)
+	}
+	assertClose(t, "data", got.Data, want.Data, 1e-12)
+
+	Tanh(got).Backward()
+	Tanh(want).Backward()
+	for i := range a {
+		assertClose(t, "grad", a[i].Grad, b[i].Grad, 1e-12)
+	}
+
+	// one node however many terms, where nested Add needs n-1
+	if n := len(TopoSort(got)); n != len(a)+1 {
+		t.Errorf("AddN graph has %d nodes, want %d", n, len(a)+1)
+	}
+
+	// a repeated pointer gets one gradient per occurrence
+	x := NewValue(2, "x")
+	AddN(x, x, x).Backward()
+	assertClose(t, "repeated grad", x.Grad, 3, 1e-12)
+}
+
+func TestDebugForward(t *testing.T) {
+	x := NewValue(2, "x")
+	if out := Log(Neg(x)); !math.IsNaN(out.Data) {
+		t.Fatalf("guard off: got %g, want NaN to pass through", out.Data)
+	}
+
+	restore := SetDebugForward(true)
+	defer restore()
+	defer func() {
+		msg := fmt.Sprint(recover())
+		if !strings.Contains(msg, `"log(-x)"`) {
+			t.Errorf("panic %q does not name the log node", msg)
+		}
+	}()
+	Log(Neg(x))
+	t.Error("guard on: expected a panic")
+}
+
+func TestRecomputeThenBackward(t *testing.T) {
+	out, x := sampleGraph(0.5, 2, 3)
+	out.Backward()
+	for _, data := range []float64{-1.3, 0, 2.2} {
+		x.SetData(data)
+		out.Recompute()
+		out.Backward()
+
+		want, wantX := sampleGraph(data, 2, 3)
+		want.Backward()
+		assertClose(t, "data", out.Data, want.Data, 1e-12)
+		assertClose(t, "grad", x.Grad, wantX.Grad, 1e-12)
+	}
+}
+
+func TestSetData(t *testing.T) {
+	x := NewValue(0.5, "x")
+	old := Tanh(Mul(x, NewValue(2, "w")))
+	other := Exp(x)
+	x.SetData(1.5)
+
+	// graphs built after the write are up to date
+	fresh := Tanh(Mul(x, NewValue(2, "w")))
+	if err := fresh.BackwardSafe(); err != nil {
+		t.Fatalf("graph built after SetData: %v", err)
+	}
+	assertClose(t, "fresh grad", x.Grad, 2*(1-math.Pow(math.Tanh(3), 2)), 1e-12)
+
+	// graphs built before it fail until recomputed
+	err := old.BackwardSafe()
+	if err == nil || !strings.Contains(err.Error(), `"x"`) {
+		t.Fatalf("stale graph: got error %v, want one naming x", err)
+	}
+	assertPanics(t, "stale Backward", func() { old.Backward() })
+
+	old.Recompute()
+	if err := old.BackwardSafe(); err != nil {
+		t.Fatalf("after Recompute: %v", err)
+	}
+	assertClose(t, "recomputed grad", x.Grad, 2*(1-math.Pow(math.Tanh(3), 2)), 1e-12)
+
+	// recomputing one graph leaves another graph on the same leaf stale
+	if err := other.BackwardSafe(); err == nil {
+		t.Error("other graph on x: expected a stale error")
+	}
+}
+
+func TestRecomputeSharedLeaf(t *testing.T) {
+	// a train graph and an eval graph on one parameter
+	w := NewParam(0.5, "w")
+	train := Tanh(Mul(w, NewValue(2, "a")))
+	eval := Exp(Mul(w, NewValue(-1, "b")))
+
+	// re-evaluating one graph without changing data leaves the other usable
+	train.Recompute()
+	Compile(train, nil)(nil)
+	if err := eval.BackwardSafe(); err != nil {
+		t.Fatalf("eval after recomputing train: %v", err)
+	}
+	assertClose(t, "eval grad", w.Grad, -math.Exp(-0.5), 1e-12)
+	if err := train.BackwardSafe(); err != nil {
+		t.Fatalf("train after Recompute: %v", err)
+	}
+
+	// a compiled call writes its inputs with SetData, so other graphs on them go stale
+	in := NewValue(1, "in")
+	compiled := Compile(Tanh(Mul(w, in)), []*Value{in})
+	other := Exp(in)
+	compiled([]float64{3})
+	if err := other.BackwardSafe(); err == nil {
+		t.Error("graph on a compiled input: expected a stale error")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	out, _ := diamond(0.7)
+	order := TopoSort(out)
+
+	var forward, backward []*Value
+	seen := map[*Value]int{}
+	out.WalkTopo(func(n *Value) {
+		forward = append(forward, n)
+		seen[n]++
+	})
+	out.WalkReverseTopo(func(n *Value) {
+		backward = append(backward, n)
+	})
+
+	if len(forward) != len(order) || len(backward) != len(order) {
+		t.Fatalf("visited %d forward and %d backward, want %d", len(forward), len(backward), len(order))
+	}
+	for i := range order {
+		if forward[i] != order[i] {
+			t.Errorf("WalkTopo node %d is %q, want %q", i, forward[i].label, order[i].label)
+		}
+		if backward[i] != order[len(order)-1-i] {
+			t.Errorf("WalkReverseTopo node %d is %q, want %q", i, backward[i].label, order[len(order)-1-i].label)
+		}
+	}
+	for n, count := range seen {
+		if count != 1 {
+			t.Errorf("%q visited %d times", n.label, count)
+		}
+	}
+}
+
+func TestNumGradEps(t *testing.T) {
+	// the relative truncation error of exp is eps^2/6 at any x, while round-off
+	// in exp(x±eps) grows with x; 2e-5 balances the two where 1e-6 is
+	// round-off dominated
+	for _, x := range []float64{20, 30, 40, 50} {
+		exact := math.Exp(x)
+		tuned := relError(NumGrad(math.Exp, x, 2e-5), exact)
+		def := relError(NumGradDefault(math.Exp, x), exact)
+		if tuned > def/10 {
+			t.Errorf("x=%g: tuned eps error %g, default %g", x, tuned, def)
+		}
+	}
+}
+
+func TestParameters(t *testing.T) {
+	w1, w2, b := NewParam(0.5, "w1"), NewParam(-1, "w2"), NewParam(0.1, "b")
+	x, c := NewValue(2, "x"), NewValue(3, "c")
+	// w1 is used twice and the constants sit between the params
+	loss := Add(Mul(Tanh(Add(Mul(w1, x), b)), w2), Mul(w1, c))
+
+	got, want := loss.Parameters(), []*Value{w1, b, w2}
+	if len(got) != len(want) {
+		t.Fatalf("%d params, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("param %d is %q, want %q", i, got[i].Label(), want[i].Label())
+		}
+	}
+	if x.Trainable() || c.Trainable() || loss.Trainable() {
+		t.Error("constants or intermediates report trainable")
+	}
+}
+
+func TestCollectParams(t *testing.T) {
+	labels := func(vs []*Value) string {
+		out := make([]string, len(vs))
+		for i, v := range vs {
+			out[i] = v.Label()
+		}
+		return strings.Join(out, ",")
+	}
+
+	// tanh(2x+3) with all three leaves trainable
+	w, x, b := NewParam(2, "w"), NewParam(0.5, "x"), NewParam(3, "b")
+	f := Tanh(Add(Mul(w, x), b))
+	for run := 0; run < 10; run++ {
+		if got := labels(CollectParams(f)); got != "w,x,b" {
+			t.Fatalf("run %d: params %s, want w,x,b", run, got)
+		}
+	}
+
+	// inputs, constants and detached nodes are not parameters
+	restore := SetGradEnabled(false)
+	detached := Tanh(w)
+	restore()
+	mixed := AddN(Mul(w, NewValue(1, "in")), NewConst(3, "3"), detached)
+	if got := labels(CollectParams(mixed)); got != "w" {
+		t.Errorf("mixed leaves: params %s, want w", got)
+	}
+
+	// x feeds both products but is collected once
+	shared := Add(Mul(x, x), Mul(x, b))
+	if got := labels(CollectParams(shared)); got != "x,b" {
+		t.Errorf("shared params %s, want x,b", got)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	f, x := tanhSample(0)
+	compiled := Compile(f, []*Value{x})
+	for _, data := range []float64{-3, -1.5, 0, 0.25, 2} {
+		want := math.Tanh(2*data + 3)
+		assertClose(t, "compiled", compiled([]float64{data}), want, 1e-12)
+
+		// the graph is updated in place, so it can be differentiated as well
+		f.Backward()
+		assertClose(t, "grad", x.Grad, 2*(1-want*want), 1e-12)
+	}
+
+	assertPanics(t, "wrong input count", func() { compiled([]float64{1, 2}) })
+}
+
+func TestSquaredDistance(t *testing.T) {
+	dist := func(xs []float64) float64 {
+		sum := 0.0
+		for i := 0; i < 3; i++ {
+			sum += (xs[i] - xs[i+3]) * (xs[i] - xs[i+3])
+		}
+		return sum
+	}
+	xs := []float64{1, -2, 0.5, 3, 0, 0.5}
+	vs := values(xs...)
+	d := SquaredDistance(vs[:3], vs[3:])
+	assertClose(t, "data", d.Data, 4+4+0, 1e-12)
+
+	d.Backward()
+	for i, v := range vs {
+		assertClose(t, "grad", v.Grad, partial(dist, xs, i), 1e-6)
+	}
+
+	// a slice against itself is at distance 0 with no gradient
+	same := values(1, -2)
+	d = SquaredDistance(same, same)
+	d.Backward()
+	if d.Data != 0 || same[0].Grad != 0 || same[1].Grad != 0 {
+		t.Errorf("same slice: distance %g, grads %g %g, want all 0", d.Data, same[0].Grad, same[1].Grad)
+	}
+
+	assertPanics(t, "length mismatch", func() { SquaredDistance(vs[:2], vs[3:]) })
+}
@@ -0,0 +1,91 @@
+package main
+
+import "fmt"
+
+// 2-D matrix of Values, all operations build the underlying graph
+type ValueMatrix struct {
+	data [][]*Value
+	rows int
+	cols int
+}
+
+// Constructor, wraps the given rows without copying the Values
+func NewValueMatrix(data [][]*Value) *ValueMatrix {
+	m := &ValueMatrix{data: data, rows: len(data)}
+	if m.rows > 0 {
+		m.cols = len(data[0])
+	}
+	for i, row := range data {
+		if len(row) != m.cols {
+			panic(fmt.Sprintf("NewValueMatrix: row %d has %d columns, expected %d", i, len(row), m.cols))
+		}
+	}
+	return m
+}
+
+func (m *ValueMatrix) Rows() int {
+	return m.rows
+}
+
+func (m *ValueMatrix) Cols() int {
+	return m.cols
+}
+
+func (m *ValueMatrix) At(i, j int) *Value {
+	return m.data[i][j]
+}
+
+// Build a matrix of the given shape from a function of the position
+func newValueMatrix(rows, cols int, fn func(i, j int) *Value) *ValueMatrix {
+	data := make([][]*Value, rows)
+	for i := range data {
+		data[i] = make([]*Value, cols)
+		for j := range data[i] {
+			data[i][j] = fn(i, j)
+		}
+	}
+	return &ValueMatrix{data: data, rows: rows, cols: cols}
+}
+
+func (m *ValueMatrix) checkSameShape(op string, o *ValueMatrix) {
+	if m.rows != o.rows || m.cols != o.cols {
+		panic(fmt.Sprintf("%s: shape mismatch (%dx%d) and (%dx%d)", op, m.rows, m.cols, o.rows, o.cols))
+	}
+}
+
+// Element-wise sum
+func (m *ValueMatrix) Add(o *ValueMatrix) *ValueMatrix {
+	m.checkSameShape("Add", o)
+	return newValueMatrix(m.rows, m.cols, func(i, j int) *Value {
+		return Add(m.data[i][j], o.data[i][j])
+	})
+}
+
+// Element-wise product
+func (m *ValueMatrix) Mul(o *ValueMatrix) *ValueMatrix {
+	m.checkSameShape("Mul", o)
+	return newValueMatrix(m.rows, m.cols, func(i, j int) *Value {
+		return Mul(m.data[i][j], o.data[i][j])
+	})
+}
+
+// Matrix product of (n x k) and (k x p) into (n x p)
+func (m *ValueMatrix) MatMul(o *ValueMatrix) *ValueMatrix {
+	if m.cols != o.rows {
+		panic(fmt.Sprintf("MatMul: inner dims differ (%dx%d) and (%dx%d)", m.rows, m.cols, o.rows, o.cols))
+	}
+	return newValueMatrix(m.rows, o.cols, func(i, j int) *Value {
+		terms := make([]*Value, m.cols)
+		for k := range terms {
+			terms[k] = Mul(m.data[i][k], o.data[k][j])
+		}
+		return AddN(terms...)
+	})
+}
+
+// Transpose sharing the same Values, so gradients flow back to the originals
+func (m *ValueMatrix) Transpose() *ValueMatrix {
+	return newValueMatrix(m.cols, m.rows, func(i, j int) *Value {
+		return m.data[j][i]
+	})
+}
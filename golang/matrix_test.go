@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+// Matrix of fresh leaves from row-major data
+func matrixOf(rows, cols int, xs []float64) *ValueMatrix {
+	data := make([][]*Value, rows)
+	vs := values(xs...)
+	for i := range data {
+		data[i] = vs[i*cols : (i+1)*cols]
+	}
+	return NewValueMatrix(data)
+}
+
+// Row-major entries of a matrix
+func entries(m *ValueMatrix) []*Value {
+	out := []*Value{}
+	for i := 0; i < m.Rows(); i++ {
+		for j := 0; j < m.Cols(); j++ {
+			out = append(out, m.At(i, j))
+		}
+	}
+	return out
+}
+
+func TestMatMul(t *testing.T) {
+	aData := []float64{1, 2, 3, 4, 5, 6}
+	bData := []float64{7, 8, 9, 10, 11, 12}
+	a, b := matrixOf(2, 3, aData), matrixOf(3, 2, bData)
+	c := a.MatMul(b)
+	if c.Rows() != 2 || c.Cols() != 2 {
+		t.Fatalf("shape %dx%d, want 2x2", c.Rows(), c.Cols())
+	}
+	want := []float64{58, 64, 139, 154}
+	for i, v := range entries(c) {
+		assertClose(t, "entry", v.Data, want[i], 1e-12)
+	}
+
+	weightedSquares(entries(c)).Backward()
+	loss := func(xs []float64) float64 {
+		return weightedSquaresData(batchMatMulData(xs[:6], xs[6:], 1, 2, 3, 2))
+	}
+	xs := append(append([]float64{}, aData...), bData...)
+	for i, v := range append(entries(a), entries(b)...) {
+		assertClose(t, "grad", v.Grad, partial(loss, xs, i), 1e-3)
+	}
+
+	assertPanics(t, "inner mismatch", func() { a.MatMul(a) })
+}
+
+func TestTranspose(t *testing.T) {
+	a := matrixOf(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	at := a.Transpose()
+	if at.Rows() != 3 || at.Cols() != 2 {
+		t.Fatalf("shape %dx%d, want 3x2", at.Rows(), at.Cols())
+	}
+	back := at.Transpose()
+	for i := 0; i < a.Rows(); i++ {
+		for j := 0; j < a.Cols(); j++ {
+			if at.At(j, i) != a.At(i, j) || back.At(i, j) != a.At(i, j) {
+				t.Fatalf("(%d,%d) does not share the original Value", i, j)
+			}
+		}
+	}
+
+	// a^T a is symmetric and its gradients land on the originals
+	gram := at.MatMul(a)
+	weightedSquares(entries(gram)).Backward()
+	for _, v := range entries(a) {
+		if v.Grad == 0 {
+			t.Errorf("%g got no gradient through the transpose", v.Data)
+		}
+	}
+	for i := 0; i < gram.Rows(); i++ {
+		for j := 0; j < gram.Cols(); j++ {
+			assertClose(t, "symmetric", gram.At(i, j).Data, gram.At(j, i).Data, 1e-12)
+		}
+	}
+}
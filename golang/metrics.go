@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Mean absolute percentage error, mean(|(t-p)/t|) * 100. Entries with a zero
+// target have no defined percentage and are skipped; if every target is zero
+// the result is 0
+func MAPE(preds, targets []float64) float64 {
+	if len(preds) != len(targets) {
+		panic(fmt.Sprintf("MAPE: %d predictions but %d targets", len(preds), len(targets)))
+	}
+
+	sum, n := 0.0, 0
+	for i, t := range targets {
+		if t == 0 {
+			continue
+		}
+		sum += math.Abs((t - preds[i]) / t)
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n) * 100
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestMAPE(t *testing.T) {
+	tests := []struct {
+		name           string
+		preds, targets []float64
+		want           float64
+	}{
+		{"exact", []float64{1, -2, 3}, []float64{1, -2, 3}, 0},
+		// |10%| and |-50%| averaged
+		{"mixed", []float64{110, -1}, []float64{100, -2}, 30},
+		{"zero target skipped", []float64{110, 5}, []float64{100, 0}, 10},
+		{"all targets zero", []float64{1, 2}, []float64{0, 0}, 0},
+		{"empty", nil, nil, 0},
+	}
+	for _, tt := range tests {
+		assertClose(t, tt.name, MAPE(tt.preds, tt.targets), tt.want, 1e-9)
+	}
+
+	assertPanics(t, "length mismatch", func() { MAPE([]float64{1}, []float64{1, 2}) })
+}
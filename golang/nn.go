@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Building block of a network
+type Module interface {
+	Forward(x []*Value) []*Value
+	Parameters() []*Value
+}
+
+// Element-wise activation usable as a module, e.g. Activation(ReLU)
+type Activation func(*Value) *Value
+
+func (a Activation) Forward(x []*Value) []*Value {
+	out := make([]*Value, len(x))
+	for i, v := range x {
+		out[i] = a(v)
+	}
+	return out
+}
+
+func (a Activation) Parameters() []*Value {
+	return nil
+}
+
+// Modules applied one after another
+type Sequential struct {
+	Modules []Module
+}
+
+// Constructor
+func NewSequential(modules ...Module) *Sequential {
+	return &Sequential{Modules: modules}
+}
+
+func (s *Sequential) Forward(x []*Value) []*Value {
+	for _, m := range s.Modules {
+		x = m.Forward(x)
+	}
+	return x
+}
+
+func (s *Sequential) Parameters() []*Value {
+	params := []*Value{}
+	for _, m := range s.Modules {
+		params = append(params, m.Parameters()...)
+	}
+	return params
+}
+
+// Affine layer W x + b with no activation
+type Linear struct {
+	W [][]*Value
+	B []*Value
+}
+
+func newLinear(r *rand.Rand, nin, nout int) *Linear {
+	l := &Linear{
+		W: make([][]*Value, nout),
+		B: make([]*Value, nout),
+	}
+	for j := 0; j < nout; j++ {
+		l.W[j] = make([]*Value, nin)
+		for i := 0; i < nin; i++ {
+			l.W[j][i] = NewParam(r.Float64()*2-1, fmt.Sprintf("w[%d][%d]", j, i))
+		}
+		l.B[j] = NewParam(r.Float64()*2-1, fmt.Sprintf("b[%d]", j))
+	}
+	return l
+}
+
+// Constructor, weights and biases are drawn uniformly from [-1, 1] using the package RNG
+func NewLinear(nin, nout int) *Linear {
+	checkNotParallel("NewLinear")
+	return newLinear(rng, nin, nout)
+}
+
+// Constructor with a dedicated RNG
+func NewLinearSeeded(seed int64, nin, nout int) *Linear {
+	return newLinear(rand.New(rand.NewSource(seed)), nin, nout)
+}
+
+func (l *Linear) Forward(x []*Value) []*Value {
+	out := make([]*Value, len(l.W))
+	for j, row := range l.W {
+		if len(x) != len(row) {
+			panic(fmt.Sprintf("Linear: expected %d inputs, got %d", len(row), len(x)))
+		}
+		terms := []*Value{l.B[j]}
+		for i, w := range row {
+			terms = append(terms, Mul(w, x[i]))
+		}
+		out[j] = AddN(terms...)
+	}
+	return out
+}
+
+// Weights in row-major order followed by the biases
+func (l *Linear) Parameters() []*Value {
+	params := []*Value{}
+	for _, row := range l.W {
+		params = append(params, row...)
+	}
+	return append(params, l.B...)
+}
+
+// Single tanh unit tanh(w . x + b)
+type Neuron struct {
+	W []*Value
+	B *Value
+}
+
+func newNeuron(r *rand.Rand, nin int) *Neuron {
+	n := &Neuron{W: make([]*Value, nin)}
+	for i := range n.W {
+		n.W[i] = NewParam(r.Float64()*2-1, fmt.Sprintf("w[%d]", i))
+	}
+	n.B = NewParam(r.Float64()*2-1, "b")
+	return n
+}
+
+// Constructor, weights are drawn uniformly from [-1, 1] using the package RNG
+func NewNeuron(nin int) *Neuron {
+	checkNotParallel("NewNeuron")
+	return newNeuron(rng, nin)
+}
+
+// Constructor with a dedicated RNG, so the weights only depend on the seed
+func NewNeuronSeeded(seed int64, nin int) *Neuron {
+	return newNeuron(rand.New(rand.NewSource(seed)), nin)
+}
+
+func (n *Neuron) Forward(x []*Value) *Value {
+	if len(x) != len(n.W) {
+		panic(fmt.Sprintf("Neuron: expected %d inputs, got %d", len(n.W), len(x)))
+	}
+	terms := []*Value{n.B}
+	for i, w := range n.W {
+		terms = append(terms, Mul(w, x[i]))
+	}
+	return Tanh(AddN(terms...))
+}
+
+func (n *Neuron) Parameters() []*Value {
+	return append(append([]*Value{}, n.W...), n.B)
+}
+
+// Layer of independent neurons over the same inputs
+type Layer struct {
+	Neurons []*Neuron
+}
+
+func newLayer(r *rand.Rand, nin, nout int) *Layer {
+	l := &Layer{Neurons: make([]*Neuron, nout)}
+	for i := range l.Neurons {
+		l.Neurons[i] = newNeuron(r, nin)
+	}
+	return l
+}
+
+// Constructor using the package RNG
+func NewLayer(nin, nout int) *Layer {
+	checkNotParallel("NewLayer")
+	return newLayer(rng, nin, nout)
+}
+
+// Constructor with a dedicated RNG
+func NewLayerSeeded(seed int64, nin, nout int) *Layer {
+	return newLayer(rand.New(rand.NewSource(seed)), nin, nout)
+}
+
+func (l *Layer) Forward(x []*Value) []*Value {
+	out := make([]*Value, len(l.Neurons))
+	for i, n := range l.Neurons {
+		out[i] = n.Forward(x)
+	}
+	return out
+}
+
+func (l *Layer) Parameters() []*Value {
+	params := []*Value{}
+	for _, n := range l.Neurons {
+		params = append(params, n.Parameters()...)
+	}
+	return params
+}
+
+// Multi-layer perceptron of tanh layers
+type MLP struct {
+	Layers []*Layer
+}
+
+func newMLP(r *rand.Rand, nin int, nouts []int) *MLP {
+	m := &MLP{Layers: make([]*Layer, len(nouts))}
+	for i, nout := range nouts {
+		m.Layers[i] = newLayer(r, nin, nout)
+		nin = nout
+	}
+	return m
+}
+
+// Constructor using the package RNG
+func NewMLP(nin int, nouts []int) *MLP {
+	checkNotParallel("NewMLP")
+	return newMLP(rng, nin, nouts)
+}
+
+// Constructor with a dedicated RNG, so construction is deterministic regardless
+// of what else has used the package RNG
+func NewMLPSeeded(seed int64, nin int, nouts []int) *MLP {
+	return newMLP(rand.New(rand.NewSource(seed)), nin, nouts)
+}
+
+func (m *MLP) Forward(x []*Value) []*Value {
+	for _, l := range m.Layers {
+		x = l.Forward(x)
+	}
+	return x
+}
+
+func (m *MLP) Parameters() []*Value {
+	params := []*Value{}
+	for _, l := range m.Layers {
+		params = append(params, l.Parameters()...)
+	}
+	return params
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinear(t *testing.T) {
+	Seed(1)
+	l := NewLinear(3, 2)
+	if n := len(l.Parameters()); n != 3*2+2 {
+		t.Fatalf("%d parameters, want 8", n)
+	}
+
+	forward := func(xs ...float64) []float64 {
+		return FlattenParams(l.Forward(values(xs...)))
+	}
+	// affine: f(x + y) - f(0) = (f(x) - f(0)) + (f(y) - f(0))
+	zero, fx, fy, fxy := forward(0, 0, 0), forward(1, -2, 0.5), forward(0.3, 4, -1), forward(1.3, 2, -0.5)
+	for j := range zero {
+		assertClose(t, "bias", zero[j], l.B[j].Data, 1e-12)
+		assertClose(t, "affine", fxy[j]-zero[j], fx[j]-zero[j]+fy[j]-zero[j], 1e-12)
+	}
+
+	// d sum(out) / dW[j][i] = x[i] and / dB[j] = 1
+	x := values(1, -2, 0.5)
+	AddN(l.Forward(x)...).Backward()
+	for j := range l.W {
+		for i, w := range l.W[j] {
+			assertClose(t, w.Label(), w.Grad, x[i].Data, 1e-12)
+		}
+		assertClose(t, l.B[j].Label(), l.B[j].Grad, 1, 1e-12)
+	}
+
+	assertPanics(t, "wrong input size", func() { l.Forward(values(1, 2)) })
+}
+
+func TestSequential(t *testing.T) {
+	Seed(2)
+	first, second := NewLinear(3, 4), NewLinear(4, 2)
+	model := NewSequential(first, Activation(ReLU), second)
+	if got, want := len(model.Parameters()), len(first.Parameters())+len(second.Parameters()); got != want {
+		t.Fatalf("%d parameters, want %d", got, want)
+	}
+
+	x := values(0.5, -1, 2)
+	out := model.Forward(x)
+	if len(out) != 2 {
+		t.Fatalf("output size %d, want 2", len(out))
+	}
+	hidden := Activation(ReLU).Forward(first.Forward(values(0.5, -1, 2)))
+	want := FlattenParams(second.Forward(hidden))
+	for j := range out {
+		assertClose(t, "output", out[j].Data, want[j], 1e-12)
+	}
+
+	// gradients reach the inputs and every parameter of both layers
+	f := func(xs []float64) float64 {
+		sum := 0.0
+		for _, v := range model.Forward(values(xs...)) {
+			sum += v.Data
+		}
+		return sum
+	}
+	AddN(out...).Backward()
+	xs := []float64{0.5, -1, 2}
+	for i := range x {
+		assertClose(t, "input grad", x[i].Grad, partial(f, xs, i), 1e-6)
+	}
+	for _, p := range model.Parameters() {
+		want := NumGradDefault(func(v float64) float64 {
+			old := p.Data
+			p.Data = v
+			defer func() { p.Data = old }()
+			return f(xs)
+		}, p.Data)
+		assertClose(t, p.Label(), p.Grad, want, 1e-6)
+	}
+}
+
+func TestSeededConstructors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() []*Value
+	}{
+		{"linear", func() []*Value { return NewLinearSeeded(7, 3, 2).Parameters() }},
+		{"neuron", func() []*Value { return NewNeuronSeeded(7, 3).Parameters() }},
+		{"layer", func() []*Value { return NewLayerSeeded(7, 3, 2).Parameters() }},
+		{"mlp", func() []*Value { return NewMLPSeeded(7, 3, []int{4, 1}).Parameters() }},
+	}
+	for _, tt := range tests {
+		first := FlattenParams(tt.build())
+		// drawing from the package RNG in between must not change the weights
+		Permutation(10)
+		NewLinear(3, 3)
+		second := FlattenParams(tt.build())
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("%s: same seed gave %v then %v", tt.name, first, second)
+		}
+	}
+
+	if reflect.DeepEqual(FlattenParams(NewLinearSeeded(7, 3, 2).Parameters()), FlattenParams(NewLinearSeeded(8, 3, 2).Parameters())) {
+		t.Error("different seeds gave the same weights")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import "math"
+
+// Updates a set of parameters from their gradients
+type Optimizer interface {
+	Step()
+	ZeroGrad()
+	LR() float64
+	SetLR(lr float64)
+}
+
+// Plain gradient descent
+type SGD struct {
+	params []*Value
+	lr     float64
+}
+
+// Constructor
+func NewSGD(params []*Value, lr float64) *SGD {
+	return &SGD{params: params, lr: lr}
+}
+
+func (o *SGD) Step() {
+	for _, p := range o.params {
+		p.Data -= o.lr * p.Grad
+	}
+}
+
+func (o *SGD) ZeroGrad() {
+	ZeroGradParams(o.params)
+}
+
+func (o *SGD) LR() float64 {
+	return o.lr
+}
+
+func (o *SGD) SetLR(lr float64) {
+	o.lr = lr
+}
+
+// Learning-rate range test: take steps optimizer steps with the learning rate
+// growing exponentially from minLR to maxLR, recording the loss before each
+// step. The parameters are trained along the way, so take a SnapshotParams
+// first if they should be restored; the LR is restored at the end
+func LRFind(opt Optimizer, buildLoss func() *Value, minLR, maxLR float64, steps int) (lrs, losses []float64) {
+	defer opt.SetLR(opt.LR())
+
+	for i := 0; i < steps; i++ {
+		lr := minLR
+		if steps > 1 {
+			lr = minLR * math.Pow(maxLR/minLR, float64(i)/float64(steps-1))
+		}
+		opt.SetLR(lr)
+
+		loss := buildLoss()
+		lrs = append(lrs, lr)
+		losses = append(losses, loss.Data)
+
+		opt.ZeroGrad()
+		loss.Backward()
+		opt.Step()
+	}
+	return lrs, losses
+}
+
+// Moment estimates of one parameter; each parameter keeps its own timestep so
+// it can be reset independently
+type adamState struct {
+	m, v float64
+	t    int
+}
+
+// Adam with bias-corrected first and second moment estimates
+type Adam struct {
+	params []*Value
+	lr     float64
+	beta1  float64
+	beta2  float64
+	eps    float64
+	state  map[*Value]*adamState
+}
+
+// Constructor with the usual defaults beta1=0.9, beta2=0.999, eps=1e-8
+func NewAdam(params []*Value, lr float64) *Adam {
+	return &Adam{
+		params: params,
+		lr:     lr,
+		beta1:  0.9,
+		beta2:  0.999,
+		eps:    1e-8,
+		state:  map[*Value]*adamState{},
+	}
+}
+
+func (o *Adam) Step() {
+	for _, p := range o.params {
+		s, ok := o.state[p]
+		if !ok {
+			s = &adamState{}
+			o.state[p] = s
+		}
+		s.t++
+		s.m = o.beta1*s.m + (1-o.beta1)*p.Grad
+		s.v = o.beta2*s.v + (1-o.beta2)*p.Grad*p.Grad
+		mHat := s.m / (1 - math.Pow(o.beta1, float64(s.t)))
+		vHat := s.v / (1 - math.Pow(o.beta2, float64(s.t)))
+		p.Data -= o.lr * mHat / (math.Sqrt(vHat) + o.eps)
+	}
+}
+
+func (o *Adam) ZeroGrad() {
+	ZeroGradParams(o.params)
+}
+
+func (o *Adam) LR() float64 {
+	return o.lr
+}
+
+func (o *Adam) SetLR(lr float64) {
+	o.lr = lr
+}
+
+// Clear the moments and timestep of just the listed parameters, so they are
+// updated like fresh ones on the next Step, e.g. after unfreezing a layer
+func (o *Adam) ResetState(params []*Value) {
+	for _, p := range params {
+		delete(o.state, p)
+	}
+}
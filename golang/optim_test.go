@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestLRFind(t *testing.T) {
+	w := NewParam(1, "w")
+	opt := NewSGD([]*Value{w}, 0.05)
+	// SGD on w^2 scales w by (1 - 2 lr): converges for lr < 1, diverges beyond
+	lrs, losses := LRFind(opt, func() *Value { return Mul(w, w) }, 1e-3, 10, 30)
+
+	if len(lrs) != 30 || len(losses) != 30 {
+		t.Fatalf("got %d lrs and %d losses, want 30", len(lrs), len(losses))
+	}
+	assertClose(t, "first lr", lrs[0], 1e-3, 1e-15)
+	assertClose(t, "last lr", lrs[29], 10, 1e-9)
+	for i := 1; i < len(lrs); i++ {
+		assertClose(t, "lr ratio", lrs[i]/lrs[i-1], lrs[1]/lrs[0], 1e-9)
+	}
+
+	best := 0
+	for i, l := range losses {
+		if l < losses[best] {
+			best = i
+		}
+	}
+	if best == 0 || best == len(losses)-1 {
+		t.Fatalf("losses %v do not drop and then rise", losses)
+	}
+	// each loss is measured before its step, so the lowest one follows the step at lrs[best-1]
+	if lrs[best-1] > 1 {
+		t.Errorf("lowest loss after a step at lr %g, past the divergence point 1", lrs[best-1])
+	}
+	if losses[len(losses)-1] <= losses[0] {
+		t.Errorf("final loss %g did not blow up past the start %g", losses[len(losses)-1], losses[0])
+	}
+	if opt.LR() != 0.05 {
+		t.Errorf("lr %g after the sweep, want 0.05 restored", opt.LR())
+	}
+}
+
+func TestAdamResetState(t *testing.T) {
+	a, b := NewParam(1, "a"), NewParam(-2, "b")
+	ref := NewParam(-2, "b")
+	opt, refOpt := NewAdam([]*Value{a, b}, 0.1), NewAdam([]*Value{ref}, 0.1)
+	grads := []float64{0.5, -1, 2, 0.3}
+	step := func(k int) {
+		a.Grad, b.Grad, ref.Grad = grads[k], -grads[k], -grads[k]
+		opt.Step()
+		refOpt.Step()
+	}
+	for k := 0; k < 3; k++ {
+		step(k)
+	}
+
+	opt.ResetState([]*Value{a})
+	fresh := NewParam(a.Data, "fresh")
+	freshOpt := NewAdam([]*Value{fresh}, 0.1)
+	fresh.Grad = grads[3]
+	freshOpt.Step()
+	step(3)
+
+	assertClose(t, "reset param", a.Data, fresh.Data, 1e-12)
+	assertClose(t, "other param", b.Data, ref.Data, 1e-12)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Copy the data of all parameters into a flat vector, in order
+func FlattenParams(params []*Value) []float64 {
+	flat := make([]float64, len(params))
+	for i, p := range params {
+		flat[i] = p.Data
+	}
+	return flat
+}
+
+// Copy the gradients of all parameters into a flat vector, in order
+func FlattenGrads(params []*Value) []float64 {
+	flat := make([]float64, len(params))
+	for i, p := range params {
+		flat[i] = p.Grad
+	}
+	return flat
+}
+
+// Write a flat vector back into the data of the parameters
+func UnflattenParams(params []*Value, flat []float64) error {
+	if len(params) != len(flat) {
+		return fmt.Errorf("unflatten: %d params but flat vector has length %d", len(params), len(flat))
+	}
+	for i, p := range params {
+		p.Data = flat[i]
+	}
+	return nil
+}
+
+// Zero the gradients of just the listed parameters, without walking the graph
+func ZeroGradParams(params []*Value) {
+	for _, p := range params {
+		p.Grad = 0
+	}
+}
+
+// Multiply the loss by scale so small gradients don't underflow; undo it with
+// UnscaleGrads after Backward and before the optimizer step
+func ScaleLoss(loss *Value, scale float64) *Value {
+	return Mul(loss, NewConst(scale, "scale"))
+}
+
+// Divide the parameter gradients back down by the loss scale
+func UnscaleGrads(params []*Value, scale float64) {
+	for _, p := range params {
+		p.Grad /= scale
+	}
+}
+
+// Min, max, mean and L2 norm of the parameter gradients; all zeros for an empty list
+func GradStats(params []*Value) (min, max, mean, l2norm float64) {
+	if len(params) == 0 {
+		return 0, 0, 0, 0
+	}
+
+	min, max = math.Inf(1), math.Inf(-1)
+	sum, sumSq := 0.0, 0.0
+	for _, p := range params {
+		min = math.Min(min, p.Grad)
+		max = math.Max(max, p.Grad)
+		sum += p.Grad
+		sumSq += p.Grad * p.Grad
+	}
+	return min, max, sum / float64(len(params)), math.Sqrt(sumSq)
+}
+
+// In-memory copy of the parameter data, e.g. to keep the best weights seen
+func SnapshotParams(params []*Value) []float64 {
+	return FlattenParams(params)
+}
+
+// Copy a snapshot back into the parameters
+func RestoreParams(params []*Value, snap []float64) error {
+	return UnflattenParams(params, snap)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFlattenParams(t *testing.T) {
+	params := values(1, -2, 3.5)
+	params[1].Grad = 4
+
+	flat := FlattenParams(params)
+	if !reflect.DeepEqual(flat, []float64{1, -2, 3.5}) {
+		t.Fatalf("flatten: got %v", flat)
+	}
+	if grads := FlattenGrads(params); !reflect.DeepEqual(grads, []float64{0, 4, 0}) {
+		t.Fatalf("flatten grads: got %v", grads)
+	}
+
+	// round trip, then write a modified vector back
+	if err := UnflattenParams(params, flat); err != nil {
+		t.Fatal(err)
+	}
+	if got := FlattenParams(params); !reflect.DeepEqual(got, flat) {
+		t.Fatalf("round trip: got %v", got)
+	}
+	if err := UnflattenParams(params, []float64{9, 8, 7}); err != nil {
+		t.Fatal(err)
+	}
+	if params[0].Data != 9 || params[1].Data != 8 || params[2].Data != 7 {
+		t.Fatalf("unflatten: got %v", FlattenParams(params))
+	}
+
+	if err := UnflattenParams(params, []float64{1}); err == nil {
+		t.Error("length mismatch: expected an error")
+	}
+}
+
+func TestZeroGradParams(t *testing.T) {
+	w, b := NewParam(2, "w"), NewParam(-1, "b")
+	x := NewValue(3, "x")
+	loss := Tanh(Add(Mul(w, x), b))
+	loss.Backward()
+
+	ZeroGradParams([]*Value{w, b})
+	if w.Grad != 0 || b.Grad != 0 {
+		t.Errorf("params: grads %g, %g, want 0", w.Grad, b.Grad)
+	}
+	// nodes outside the list keep their gradients
+	if x.Grad == 0 || loss.Grad != 1 {
+		t.Errorf("non-params: x grad %g, loss grad %g, want untouched", x.Grad, loss.Grad)
+	}
+}
+
+func TestLossScaling(t *testing.T) {
+	build := func() (*Value, []*Value) {
+		w, b := NewParam(0.3, "w"), NewParam(-0.2, "b")
+		return Tanh(Add(Mul(w, NewValue(1.5, "x")), b)), []*Value{w, b}
+	}
+
+	loss, want := build()
+	loss.Backward()
+
+	for _, scale := range []float64{1, 1024, 65536} {
+		loss, params := build()
+		scaled := ScaleLoss(loss, scale)
+		assertClose(t, "scaled loss", scaled.Data, loss.Data*scale, 1e-9)
+		scaled.Backward()
+		UnscaleGrads(params, scale)
+		for i := range params {
+			assertClose(t, "unscaled grad", params[i].Grad, want[i].Grad, 1e-12)
+		}
+	}
+}
+
+func TestGradStats(t *testing.T) {
+	tests := []struct {
+		name                   string
+		grads                  []float64
+		min, max, mean, l2norm float64
+	}{
+		{"mixed", []float64{3, -4, 0, 1}, -4, 3, 0, math.Sqrt(26)},
+		{"single", []float64{-2}, -2, -2, -2, 2},
+		{"empty", nil, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		params := values(make([]float64, len(tt.grads))...)
+		for i, g := range tt.grads {
+			params[i].Grad = g
+		}
+		min, max, mean, l2norm := GradStats(params)
+		if min != tt.min || max != tt.max || mean != tt.mean {
+			t.Errorf("%s: min %g max %g mean %g, want %g %g %g", tt.name, min, max, mean, tt.min, tt.max, tt.mean)
+		}
+		assertClose(t, tt.name+" l2 norm", l2norm, tt.l2norm, 1e-12)
+	}
+}
+
+func TestSnapshotParams(t *testing.T) {
+	model := NewMLPSeeded(5, 2, []int{3, 1})
+	params := model.Parameters()
+	snap := SnapshotParams(params)
+	before := FlattenParams(params)
+
+	opt := NewSGD(params, 0.1)
+	for step := 0; step < 5; step++ {
+		loss := Pow(model.Forward(values(0.5, -1))[0], 2)
+		opt.ZeroGrad()
+		loss.Backward()
+		opt.Step()
+	}
+	if reflect.DeepEqual(FlattenParams(params), before) {
+		t.Fatal("training did not change the params")
+	}
+	// the snapshot is a copy, not a view of the params
+	if !reflect.DeepEqual(snap, before) {
+		t.Fatalf("snapshot changed during training: %v", snap)
+	}
+
+	if err := RestoreParams(params, snap); err != nil {
+		t.Fatal(err)
+	}
+	if got := FlattenParams(params); !reflect.DeepEqual(got, before) {
+		t.Errorf("restored %v, want %v", got, before)
+	}
+	if err := RestoreParams(params, snap[1:]); err == nil {
+		t.Error("size mismatch: expected an error")
+	}
+}
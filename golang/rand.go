@@ -0,0 +1,29 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Package RNG used for shuffling and initialization, reseed it with Seed for
+// reproducible runs
+var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+func Seed(seed int64) {
+	checkNotParallel("Seed")
+	rng = rand.New(rand.NewSource(seed))
+}
+
+// Random permutation of 0..n-1 drawn from the package RNG (Fisher–Yates)
+func Permutation(n int) []int {
+	checkNotParallel("Permutation")
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+	return perm
+}
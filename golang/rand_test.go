@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestPermutation(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 10, 100} {
+		perm := Permutation(n)
+		sorted := append([]int{}, perm...)
+		sort.Ints(sorted)
+		for i, v := range sorted {
+			if v != i {
+				t.Fatalf("n=%d: %v is not a permutation of 0..%d", n, perm, n-1)
+			}
+		}
+		if len(perm) != n {
+			t.Fatalf("n=%d: got %d elements", n, len(perm))
+		}
+	}
+
+	Seed(42)
+	first := Permutation(50)
+	Seed(42)
+	if again := Permutation(50); !reflect.DeepEqual(again, first) {
+		t.Errorf("same seed gave %v then %v", first, again)
+	}
+	Seed(43)
+	if other := Permutation(50); reflect.DeepEqual(other, first) {
+		t.Error("different seeds gave the same permutation")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Histogram of the data of attached Values over many forward passes, for
+// spotting dead ReLUs or saturated tanh units
+type ActivationRecorder struct {
+	lo, hi float64
+	counts []int
+}
+
+// Constructor, bins split [lo, hi] evenly; values outside go into the edge bins
+func NewActivationRecorder(bins int, lo, hi float64) *ActivationRecorder {
+	if bins <= 0 {
+		panic(fmt.Sprintf("NewActivationRecorder: need at least one bin, got %d", bins))
+	}
+	if hi <= lo {
+		panic(fmt.Sprintf("NewActivationRecorder: empty range [%g, %g]", lo, hi))
+	}
+	return &ActivationRecorder{lo: lo, hi: hi, counts: make([]int, bins)}
+}
+
+// Record the data of the values now and every time Recompute or a Compile'd
+// graph re-evaluates them. Building a fresh graph creates new nodes, so a
+// recorder attached to the old ones will not see those passes
+func (r *ActivationRecorder) Attach(vs ...*Value) {
+	for _, v := range vs {
+		r.Record(v.Data)
+		v.OnForward(func(v *Value) {
+			r.Record(v.Data)
+		})
+	}
+}
+
+// Count x in its bin; values outside [lo, hi], including infinities, go into
+// the edge bins, and NaN is not counted
+func (r *ActivationRecorder) Record(x float64) {
+	var bin int
+	switch {
+	case math.IsNaN(x):
+		return
+	case x < r.lo:
+		bin = 0
+	case x >= r.hi:
+		bin = len(r.counts) - 1
+	default:
+		// clamp anyway, rounding can put a value just below hi past the last bin
+		bin = int(math.Floor((x - r.lo) / (r.hi - r.lo) * float64(len(r.counts))))
+		bin = min(bin, len(r.counts)-1)
+	}
+	r.counts[bin]++
+}
+
+func (r *ActivationRecorder) Histogram() []int {
+	return append([]int{}, r.counts...)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestActivationRecorder(t *testing.T) {
+	r := NewActivationRecorder(4, -1, 1)
+	for _, x := range []float64{-0.9, -0.1, 0, 0.3, 0.99, -5, 5} {
+		r.Record(x)
+	}
+	// bins [-1,-0.5) [-0.5,0) [0,0.5) [0.5,1], outliers clamped to the edges
+	if got := r.Histogram(); !reflect.DeepEqual(got, []int{2, 1, 2, 2}) {
+		t.Errorf("histogram %v, want [2 1 2 2]", got)
+	}
+
+	// values whose bin index would overflow an int still land in the edge bins
+	extreme := NewActivationRecorder(4, -1, 1)
+	for _, x := range []float64{1e300, math.Inf(1), -1e300, math.Inf(-1), math.NaN()} {
+		extreme.Record(x)
+	}
+	if got := extreme.Histogram(); !reflect.DeepEqual(got, []int{2, 0, 0, 2}) {
+		t.Errorf("extreme histogram %v, want [2 0 0 2] with NaN skipped", got)
+	}
+
+	// attached nodes are recorded at attach time and on every Recompute or compiled call
+	f, x := tanhSample(-1.5)
+	rec := NewActivationRecorder(2, -1, 1)
+	rec.Attach(f)
+	x.SetData(-2)
+	f.Recompute()
+	Compile(f, []*Value{x})([]float64{1})
+	if got := rec.Histogram(); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("attached histogram %v, want [1 2]", got)
+	}
+
+	assertPanics(t, "no bins", func() { NewActivationRecorder(0, -1, 1) })
+	assertPanics(t, "empty range", func() { NewActivationRecorder(4, 1, 1) })
+	assertPanics(t, "reversed range", func() { NewActivationRecorder(4, 1, -1) })
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type nodeJSON struct {
+	Label     string    `json:"label"`
+	Op        string    `json:"op"`
+	Data      float64   `json:"data"`
+	Grad      float64   `json:"grad"`
+	Args      []float64 `json:"args,omitempty"`
+	Parents   []int     `json:"parents,omitempty"`
+	Trainable bool      `json:"trainable,omitempty"`
+	Const     bool      `json:"const,omitempty"`
+}
+
+// Nodes in topological order, the root is the last one
+type graphJSON struct {
+	Nodes []nodeJSON `json:"nodes"`
+}
+
+// Encode the graph as JSON, recording the op kind of every node so that
+// Deserialize can rebuild working backward closures
+func (v *Value) Serialize() ([]byte, error) {
+	order := TopoSort(v)
+	index := map[*Value]int{}
+	g := graphJSON{Nodes: make([]nodeJSON, len(order))}
+	for i, n := range order {
+		if n.Op == OpCheckpoint || n.Op == OpCustom {
+			return nil, fmt.Errorf("serialize: %v node %q cannot be serialized", n.Op, n.label)
+		}
+		index[n] = i
+		parents := make([]int, len(n.parents))
+		for j, parent := range n.parents {
+			parents[j] = index[parent]
+		}
+		g.Nodes[i] = nodeJSON{
+			Label:     n.label,
+			Op:        n.Op.String(),
+			Data:      n.Data,
+			Grad:      n.Grad,
+			Args:      n.args,
+			Parents:   parents,
+			Trainable: n.trainable,
+			Const:     n.constant,
+		}
+	}
+	return json.Marshal(g)
+}
+
+// Decode a graph written by Serialize and return its root. Every node is
+// rebuilt with the constructor of its op, so the graph is differentiable again
+func Deserialize(data []byte) (*Value, error) {
+	var g graphJSON
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("deserialize: %w", err)
+	}
+	if len(g.Nodes) == 0 {
+		return nil, fmt.Errorf("deserialize: empty graph")
+	}
+
+	kinds := map[string]OpKind{}
+	for kind, name := range opNames {
+		kinds[name] = kind
+	}
+
+	defer SetGradEnabled(true)()
+	nodes := make([]*Value, len(g.Nodes))
+	for i, n := range g.Nodes {
+		kind, ok := kinds[n.Op]
+		if !ok {
+			return nil, fmt.Errorf("deserialize: node %d has unknown op %q", i, n.Op)
+		}
+		parents := make([]*Value, len(n.Parents))
+		for j, p := range n.Parents {
+			if p < 0 || p >= i {
+				return nil, fmt.Errorf("deserialize: node %d has invalid parent %d", i, p)
+			}
+			parents[j] = nodes[p]
+		}
+
+		v, err := rebuild(kind, parents, n.Args, n.Data)
+		if err != nil {
+			return nil, fmt.Errorf("deserialize: node %d: %w", i, err)
+		}
+		v.label = n.Label
+		v.Data = n.Data
+		v.Grad = n.Grad
+		v.trainable = n.Trainable && kind == OpLeaf
+		v.constant = n.Const && kind == OpLeaf
+		nodes[i] = v
+	}
+	return nodes[len(nodes)-1], nil
+}
+
+// Build a node of the given op over the parents with the standard constructor
+func rebuild(op OpKind, parents []*Value, args []float64, data float64) (*Value, error) {
+	arity := map[OpKind]int{
+		OpAdd: 2, OpMul: 2, OpHuber: 2, OpSmoothL1: 2, OpGreater: 2, OpLess: 2, OpBCEWithLogits: 2,
+		OpTanh: 1, OpExp: 1, OpLog: 1, OpNeg: 1, OpReLU: 1, OpSwish: 1, OpMish: 1, OpPow: 1, OpAffine: 1,
+	}
+	if n, ok := arity[op]; ok && len(parents) != n {
+		return nil, fmt.Errorf("%v expects %d parents, got %d", op, n, len(parents))
+	}
+	params := map[OpKind]int{OpPow: 1, OpHuber: 1, OpSmoothL1: 1, OpKLDiv: 1, OpAffine: 2}
+	if n, ok := params[op]; ok && len(args) != n {
+		return nil, fmt.Errorf("%v expects %d args, got %d", op, n, len(args))
+	}
+
+	switch op {
+	case OpLeaf:
+		return NewValue(data, ""), nil
+	case OpAdd:
+		return Add(parents[0], parents[1]), nil
+	case OpAddN:
+		return AddN(parents...), nil
+	case OpMul:
+		return Mul(parents[0], parents[1]), nil
+	case OpTanh:
+		return Tanh(parents[0]), nil
+	case OpExp:
+		return Exp(parents[0]), nil
+	case OpLog:
+		return Log(parents[0]), nil
+	case OpNeg:
+		return Neg(parents[0]), nil
+	case OpReLU:
+		return ReLU(parents[0]), nil
+	case OpSwish:
+		return Swish(parents[0]), nil
+	case OpMish:
+		return Mish(parents[0]), nil
+	case OpPow:
+		return Pow(parents[0], args[0]), nil
+	case OpAffine:
+		return Affine(parents[0], args[0], args[1]), nil
+	case OpProd:
+		return Prod(parents), nil
+	case OpMax:
+		if len(parents) == 0 {
+			return nil, fmt.Errorf("max expects at least one parent")
+		}
+		out, _ := MaxSlice(parents)
+		return out, nil
+	case OpHuber:
+		return HuberLoss(parents[0], parents[1], args[0]), nil
+	case OpSmoothL1:
+		return SmoothL1Loss(parents[0], parents[1], args[0]), nil
+	case OpKLDiv:
+		if len(parents)%2 != 0 {
+			return nil, fmt.Errorf("kldiv expects an even number of parents, got %d", len(parents))
+		}
+		n := len(parents) / 2
+		if args[0] != 0 {
+			return KLDivLossTarget(parents[:n], parents[n:]), nil
+		}
+		return KLDivLoss(parents[:n], parents[n:]), nil
+	case OpBCEWithLogits:
+		return BCEWithLogitsLoss(parents[0], parents[1]), nil
+	case OpCosineSim:
+		if len(parents) == 0 || len(parents)%2 != 0 {
+			return nil, fmt.Errorf("cosinesim expects a positive even number of parents, got %d", len(parents))
+		}
+		n := len(parents) / 2
+		return CosineSim(parents[:n], parents[n:]), nil
+	case OpGreater:
+		return GreaterThan(parents[0], parents[1]), nil
+	case OpLess:
+		return LessThan(parents[0], parents[1]), nil
+	default:
+		return nil, fmt.Errorf("%v nodes cannot be deserialized", op)
+	}
+}
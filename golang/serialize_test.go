@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSerializeRoundTrip(t *testing.T) {
+	build := func() *Value {
+		x, w := NewValue(0.6, "x"), NewParam(-1.1, "w")
+		a := values(0.2, -0.7, 1.5)
+		b := []*Value{x, w, Exp(x)}
+		h := Tanh(Affine(Mul(w, x), 2, 0.5))
+		m, _ := MaxSlice([]*Value{h, x, Log(Add(x, NewValue(2, "c")))})
+		return AddN(
+			HuberLoss(h, x, 1),
+			Pow(Swish(h), 2),
+			CosineSim(a, b),
+			m,
+			BCEWithLogitsLoss(w, NewValue(1, "t")),
+		)
+	}
+
+	orig := build()
+	data, err := orig.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orig.Backward()
+	copied.Backward()
+	want, got := TopoSort(orig), TopoSort(copied)
+	if len(got) != len(want) {
+		t.Fatalf("%d nodes after round trip, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Op != want[i].Op || got[i].label != want[i].label || got[i].trainable != want[i].trainable {
+			t.Fatalf("node %d is %v %q, want %v %q", i, got[i].Op, got[i].label, want[i].Op, want[i].label)
+		}
+		assertClose(t, want[i].label+" data", got[i].Data, want[i].Data, 1e-12)
+		assertClose(t, want[i].label+" grad", got[i].Grad, want[i].Grad, 1e-12)
+	}
+}
+
+func TestSerializeErrors(t *testing.T) {
+	x := NewValue(1, "x")
+	if _, err := square(x).Serialize(); err == nil {
+		t.Error("custom op: expected an error")
+	}
+
+	tests := []struct {
+		name, json, want string
+	}{
+		{"not json", `{`, "deserialize"},
+		{"empty", `{"nodes": []}`, "empty graph"},
+		{"unknown op", `{"nodes": [{"op": "conv"}]}`, "unknown op"},
+		{"forward parent", `{"nodes": [{"op": "leaf"}, {"op": "tanh", "parents": [1]}]}`, "invalid parent"},
+	}
+	for _, tt := range tests {
+		_, err := Deserialize([]byte(tt.json))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want one mentioning %q", tt.name, err, tt.want)
+		}
+	}
+}
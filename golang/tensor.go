@@ -0,0 +1,120 @@
+package main
+
+import "fmt"
+
+// N-dimensional array of Values stored in row-major order
+type Tensor struct {
+	shape []int
+	data  []*Value
+}
+
+// Number of elements in a tensor of the given shape
+func numel(shape []int) int {
+	n := 1
+	for _, d := range shape {
+		n *= d
+	}
+	return n
+}
+
+// Constructor
+func NewTensor(shape []int, data []float64, label string) *Tensor {
+	if numel(shape) != len(data) {
+		panic(fmt.Sprintf("NewTensor: shape %v needs %d elements, got %d", shape, numel(shape), len(data)))
+	}
+
+	values := make([]*Value, len(data))
+	for i, x := range data {
+		values[i] = NewValue(x, fmt.Sprintf("%s[%d]", label, i))
+	}
+
+	return &Tensor{shape: append([]int{}, shape...), data: values}
+}
+
+func (t *Tensor) Shape() []int {
+	return append([]int{}, t.shape...)
+}
+
+// Elements of the tensor in row-major order
+func (t *Tensor) Values() []*Value {
+	return t.data
+}
+
+// Element at the given index
+func (t *Tensor) At(idx ...int) *Value {
+	if len(idx) != len(t.shape) {
+		panic(fmt.Sprintf("At: index %v does not match shape %v", idx, t.shape))
+	}
+	flat := 0
+	for i, x := range idx {
+		if x < 0 || x >= t.shape[i] {
+			panic(fmt.Sprintf("At: index %v out of range for shape %v", idx, t.shape))
+		}
+		flat = flat*t.shape[i] + x
+	}
+	return t.data[flat]
+}
+
+// Sum along one axis, the result has that axis removed from its shape
+func (t *Tensor) SumAxis(axis int) *Tensor {
+	if axis < 0 || axis >= len(t.shape) {
+		panic(fmt.Sprintf("SumAxis: axis %d out of range for shape %v", axis, t.shape))
+	}
+
+	// view the tensor as (outer, n, inner) and reduce the middle dimension
+	outer := numel(t.shape[:axis])
+	n := t.shape[axis]
+	inner := numel(t.shape[axis+1:])
+
+	shape := append(append([]int{}, t.shape[:axis]...), t.shape[axis+1:]...)
+	data := make([]*Value, outer*inner)
+	for o := 0; o < outer; o++ {
+		for i := 0; i < inner; i++ {
+			terms := make([]*Value, n)
+			for k := range terms {
+				terms[k] = t.data[(o*n+k)*inner+i]
+			}
+			data[o*inner+i] = AddN(terms...)
+		}
+	}
+
+	return &Tensor{shape: shape, data: data}
+}
+
+// Batched matrix multiply of (batch, n, k) and (batch, k, m) tensors into (batch, n, m)
+func BatchMatMul(a, b *Tensor) *Tensor {
+	if len(a.shape) != 3 || len(b.shape) != 3 {
+		panic(fmt.Sprintf("BatchMatMul: expected 3-d tensors, got shapes %v and %v", a.shape, b.shape))
+	}
+	if a.shape[0] != b.shape[0] {
+		panic(fmt.Sprintf("BatchMatMul: batch dims differ, %v and %v", a.shape, b.shape))
+	}
+	if a.shape[2] != b.shape[1] {
+		panic(fmt.Sprintf("BatchMatMul: inner dims differ, %v and %v", a.shape, b.shape))
+	}
+
+	batch, n, k, m := a.shape[0], a.shape[1], a.shape[2], b.shape[2]
+	data := make([]*Value, batch*n*m)
+	for s := 0; s < batch; s++ {
+		for i := 0; i < n; i++ {
+			for j := 0; j < m; j++ {
+				terms := make([]*Value, k)
+				for p := range terms {
+					terms[p] = Mul(a.data[(s*n+i)*k+p], b.data[(s*k+p)*m+j])
+				}
+				data[(s*n+i)*m+j] = AddN(terms...)
+			}
+		}
+	}
+
+	return &Tensor{shape: []int{batch, n, m}, data: data}
+}
+
+// Apply a scalar op to every element, returning a tensor of the same shape
+func (t *Tensor) Apply(fn func(*Value) *Value) *Tensor {
+	data := make([]*Value, len(t.data))
+	for i, v := range t.data {
+		data[i] = fn(v)
+	}
+	return &Tensor{shape: t.Shape(), data: data}
+}
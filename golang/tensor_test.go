@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Panic check for shape and range validation
+func assertPanics(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: expected a panic", name)
+		}
+	}()
+	fn()
+}
+
+// Non-trivial scalar loss sum_i (i+1) * v_i^2 over the elements of a tensor
+func weightedSquares(vs []*Value) *Value {
+	terms := make([]*Value, len(vs))
+	for i, v := range vs {
+		terms[i] = Mul(NewValue(float64(i+1), "c"), Mul(v, v))
+	}
+	return AddN(terms...)
+}
+
+func weightedSquaresData(xs []float64) float64 {
+	sum := 0.0
+	for i, x := range xs {
+		sum += float64(i+1) * x * x
+	}
+	return sum
+}
+
+func TestSumAxis(t *testing.T) {
+	data := []float64{1, 2, 3, 4, 5, 6}
+	tests := []struct {
+		axis  int
+		shape []int
+		want  []float64
+		// plain-float version of the reduction
+		reduce func([]float64) []float64
+	}{
+		{0, []int{3}, []float64{5, 7, 9}, func(x []float64) []float64 {
+			return []float64{x[0] + x[3], x[1] + x[4], x[2] + x[5]}
+		}},
+		{1, []int{2}, []float64{6, 15}, func(x []float64) []float64 {
+			return []float64{x[0] + x[1] + x[2], x[3] + x[4] + x[5]}
+		}},
+	}
+	for _, tt := range tests {
+		x := NewTensor([]int{2, 3}, data, "x")
+		s := x.SumAxis(tt.axis)
+		if !reflect.DeepEqual(s.Shape(), tt.shape) {
+			t.Fatalf("axis %d: shape %v, want %v", tt.axis, s.Shape(), tt.shape)
+		}
+		if got := FlattenParams(s.Values()); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("axis %d: data %v, want %v", tt.axis, got, tt.want)
+		}
+
+		weightedSquares(s.Values()).Backward()
+		for _, i := range []int{0, 2, 4} {
+			want := partial(func(xs []float64) float64 { return weightedSquaresData(tt.reduce(xs)) }, data, i)
+			assertClose(t, "sum grad", x.Values()[i].Grad, want, 1e-5)
+		}
+	}
+
+	x := NewTensor([]int{2, 3}, data, "x")
+	assertPanics(t, "negative axis", func() { x.SumAxis(-1) })
+	assertPanics(t, "axis too large", func() { x.SumAxis(2) })
+}
+
+// Plain-float batched product of (batch, n, k) and (batch, k, m) row-major data
+func batchMatMulData(a, b []float64, batch, n, k, m int) []float64 {
+	out := make([]float64, batch*n*m)
+	for s := 0; s < batch; s++ {
+		for i := 0; i < n; i++ {
+			for j := 0; j < m; j++ {
+				for p := 0; p < k; p++ {
+					out[(s*n+i)*m+j] += a[(s*n+i)*k+p] * b[(s*k+p)*m+j]
+				}
+			}
+		}
+	}
+	return out
+}
+
+func TestBatchMatMul(t *testing.T) {
+	aData := []float64{1, 2, 3, 4, 5, 6, -1, 0.5, 2, 0, -3, 1}
+	bData := []float64{1, 0, 0, 1, 2, -1, 0.5, 2, -1, 1, 3, 0}
+	a := NewTensor([]int{2, 2, 3}, aData, "a")
+	b := NewTensor([]int{2, 3, 2}, bData, "b")
+	c := BatchMatMul(a, b)
+
+	if !reflect.DeepEqual(c.Shape(), []int{2, 2, 2}) {
+		t.Fatalf("shape %v, want [2 2 2]", c.Shape())
+	}
+	want := []float64{7, -1, 16, -1, 5, -1.5, 6, -3}
+	if got := FlattenParams(c.Values()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("data %v, want %v", got, want)
+	}
+
+	weightedSquares(c.Values()).Backward()
+	loss := func(xs []float64) float64 {
+		return weightedSquaresData(batchMatMulData(xs[:12], xs[12:], 2, 2, 3, 2))
+	}
+	xs := append(append([]float64{}, aData...), bData...)
+	inputs := append(a.Values(), b.Values()...)
+	for i, v := range inputs {
+		assertClose(t, "grad", v.Grad, partial(loss, xs, i), 1e-4)
+	}
+
+	assertPanics(t, "2-d operand", func() { BatchMatMul(NewTensor([]int{2, 6}, aData, "a"), b) })
+	assertPanics(t, "batch mismatch", func() { BatchMatMul(NewTensor([]int{3, 2, 2}, aData, "a"), b) })
+	assertPanics(t, "inner mismatch", func() { BatchMatMul(NewTensor([]int{2, 3, 2}, aData, "a"), b) })
+}
+
+func TestApply(t *testing.T) {
+	x := NewTensor([]int{2, 2}, []float64{-1, 2, 0.5, -3}, "x")
+	y := x.Apply(ReLU)
+	if !reflect.DeepEqual(y.Shape(), []int{2, 2}) {
+		t.Fatalf("shape %v, want [2 2]", y.Shape())
+	}
+	if got := FlattenParams(y.Values()); !reflect.DeepEqual(got, []float64{0, 2, 0.5, 0}) {
+		t.Fatalf("data %v", got)
+	}
+
+	weightedSquares(y.Values()).Backward()
+	// d/dx (i+1) relu(x)^2 is 2 (i+1) x where x > 0, else 0
+	if got := FlattenGrads(x.Values()); !reflect.DeepEqual(got, []float64{0, 8, 3, 0}) {
+		t.Errorf("grads %v, want [0 8 3 0]", got)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Stops training once the validation loss has not improved for patience epochs
+type EarlyStopper struct {
+	patience int
+	minDelta float64
+
+	best      float64
+	bestEpoch int
+	epoch     int
+	bad       int
+}
+
+// Constructor
+func NewEarlyStopper(patience int, minDelta float64) *EarlyStopper {
+	return &EarlyStopper{
+		patience:  patience,
+		minDelta:  minDelta,
+		best:      math.Inf(1),
+		bestEpoch: -1,
+	}
+}
+
+// Record the validation loss of an epoch and report whether to stop; a loss
+// only counts as an improvement if it beats the best by at least minDelta
+func (e *EarlyStopper) ShouldStop(valLoss float64) bool {
+	if valLoss < e.best-e.minDelta {
+		e.best = valLoss
+		e.bestEpoch = e.epoch
+		e.bad = 0
+	} else {
+		e.bad++
+	}
+	e.epoch++
+	return e.bad >= e.patience
+}
+
+// Best loss seen so far
+func (e *EarlyStopper) BestLoss() float64 {
+	return e.best
+}
+
+// Epoch (counted from 0) at which the best loss was seen, -1 before any call
+func (e *EarlyStopper) BestEpoch() int {
+	return e.bestEpoch
+}
+
+// Exponential moving average of a scalar, bias-corrected like Adam's moments
+type EMA struct {
+	decay float64
+	ema   float64
+	steps int
+}
+
+// Constructor
+func NewEMA(decay float64) *EMA {
+	return &EMA{decay: decay}
+}
+
+// Add a sample and return the current bias-corrected average
+func (e *EMA) Update(x float64) float64 {
+	e.ema = e.decay*e.ema + (1-e.decay)*x
+	e.steps++
+	return e.Value()
+}
+
+// Current bias-corrected average, 0 before the first update
+func (e *EMA) Value() float64 {
+	if e.steps == 0 {
+		return 0
+	}
+	// the average starts at 0, so early values are scaled back up
+	return e.ema / (1 - math.Pow(e.decay, float64(e.steps)))
+}
+
+// Run step n times, each building a fresh graph and returning its loss, and
+// leave the mean of the per-step gradients in the parameters (microbatching)
+func AccumulateAndAverage(params []*Value, n int, step func() *Value) {
+	if n <= 0 {
+		panic(fmt.Sprintf("AccumulateAndAverage: need at least one step, got %d", n))
+	}
+
+	// Backward zeroes the graph first, so sum each pass's gradients separately
+	total := make([]float64, len(params))
+	for k := 0; k < n; k++ {
+		loss := step()
+		ZeroGradParams(params)
+		loss.Backward()
+		for i, p := range params {
+			total[i] += p.Grad
+		}
+	}
+
+	for i, p := range params {
+		p.Grad = total[i] / float64(n)
+	}
+}
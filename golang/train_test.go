@@ -0,0 +1,95 @@
+package main
+
+import "testing"
+
+func TestEarlyStopper(t *testing.T) {
+	tests := []struct {
+		name      string
+		patience  int
+		minDelta  float64
+		losses    []float64
+		stopAt    int // index of the first call returning true, -1 if none
+		best      float64
+		bestEpoch int
+	}{
+		{"stops after patience", 3, 0, []float64{1, 0.9, 0.95, 0.92, 0.91}, 4, 0.9, 1},
+		{"improvement resets", 2, 0, []float64{1, 1.1, 0.8, 0.9, 0.7, 0.75, 0.8}, 6, 0.7, 4},
+		{"min delta", 2, 0.1, []float64{1, 0.95, 0.92}, 2, 1, 0},
+		{"keeps improving", 2, 0, []float64{5, 4, 3, 2, 1}, -1, 1, 4},
+	}
+
+	for _, tt := range tests {
+		e := NewEarlyStopper(tt.patience, tt.minDelta)
+		if e.BestEpoch() != -1 {
+			t.Errorf("%s: best epoch %d before any call, want -1", tt.name, e.BestEpoch())
+		}
+		stopAt := -1
+		for i, loss := range tt.losses {
+			if e.ShouldStop(loss) && stopAt == -1 {
+				stopAt = i
+			}
+		}
+		if stopAt != tt.stopAt {
+			t.Errorf("%s: stopped at %d, want %d", tt.name, stopAt, tt.stopAt)
+		}
+		if e.BestLoss() != tt.best || e.BestEpoch() != tt.bestEpoch {
+			t.Errorf("%s: best %g at %d, want %g at %d", tt.name, e.BestLoss(), e.BestEpoch(), tt.best, tt.bestEpoch)
+		}
+	}
+}
+
+func TestEMA(t *testing.T) {
+	e := NewEMA(0.9)
+	if e.Value() != 0 {
+		t.Errorf("before any update: got %g, want 0", e.Value())
+	}
+
+	// bias correction makes the first value the sample itself
+	assertClose(t, "first update", e.Update(5), 5, 1e-12)
+	// (0.9*0.1*5 + 0.1*7) / (1 - 0.81)
+	assertClose(t, "second update", e.Update(7), (0.45+0.7)/0.19, 1e-12)
+
+	// a constant stream stays at that constant from the first step on
+	c := NewEMA(0.99)
+	for i := 0; i < 10; i++ {
+		assertClose(t, "constant stream", c.Update(3), 3, 1e-12)
+	}
+
+	// after a jump the average converges to the new level
+	for i := 0; i < 2000; i++ {
+		c.Update(-1)
+	}
+	assertClose(t, "converged", c.Value(), -1, 1e-6)
+}
+
+func TestAccumulateAndAverage(t *testing.T) {
+	xs, ys := []float64{0.5, -1, 2, 0.1}, []float64{1, 0, -1, 0.3}
+	w, b := NewParam(0.4, "w"), NewParam(-0.2, "b")
+	params := []*Value{w, b}
+	example := func(k int) *Value {
+		pred := Tanh(Add(Mul(w, NewValue(xs[k], "x")), b))
+		return Pow(Sub(pred, NewValue(ys[k], "y")), 2)
+	}
+
+	// gradient of the mean loss over all examples in one graph
+	losses := make([]*Value, len(xs))
+	for k := range xs {
+		losses[k] = example(k)
+	}
+	Mul(AddN(losses...), NewValue(1/float64(len(xs)), "1/n")).Backward()
+	want := FlattenGrads(params)
+
+	// stale gradients must not leak into the average
+	w.Grad, b.Grad = 100, -100
+	k := 0
+	AccumulateAndAverage(params, len(xs), func() *Value {
+		loss := example(k)
+		k++
+		return loss
+	})
+	for i, p := range params {
+		assertClose(t, p.Label(), p.Grad, want[i], 1e-12)
+	}
+
+	assertPanics(t, "no steps", func() { AccumulateAndAverage(params, 0, func() *Value { return example(0) }) })
+}